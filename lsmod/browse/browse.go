@@ -0,0 +1,182 @@
+package browse
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/ymatsukawa/lsmod/finder"
+	"github.com/ymatsukawa/lsmod/formatter"
+)
+
+const (
+	enterAltScreen = "\033[?1049h\033[?25l"
+	leaveAltScreen = "\033[?25h\033[?1049l"
+)
+
+type Options struct {
+	Finder finder.Options
+	Color  formatter.ColorMode
+	Size   formatter.SizeStyle
+}
+
+func Run(ctx context.Context, path string, opts Options) (string, error) {
+	m, err := newModel(ctx, path, opts.Finder)
+	if err != nil {
+		return "", err
+	}
+
+	term, err := openTerminal()
+	if err != nil {
+		return "", err
+	}
+	defer term.restore()
+
+	fmt.Fprint(term.tty, enterAltScreen+clearScreen)
+	defer fmt.Fprint(term.tty, leaveAltScreen)
+
+	v := view{colors: formatter.NewPalette(opts.Color, term.tty), size: opts.Size}
+	keys := readKeys(term.tty)
+	resize := make(chan os.Signal, 1)
+	signal.Notify(resize, syscall.SIGWINCH)
+	defer signal.Stop(resize)
+
+	for {
+		width, height := term.size()
+		if err := v.render(term.tty, m, width, height); err != nil {
+			return "", fmt.Errorf("draw: %w", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-resize:
+			fmt.Fprint(term.tty, clearScreen)
+		case batch, ok := <-keys:
+			if !ok {
+				return "", fmt.Errorf("read terminal: input closed")
+			}
+			for _, k := range batch {
+				path, done, err := handle(m, k, height-1)
+				if done {
+					return path, err
+				}
+			}
+		}
+	}
+}
+
+func readKeys(tty *os.File) <-chan []keyPress {
+	ch := make(chan []keyPress)
+	go func() {
+		defer close(ch)
+		buf := make([]byte, 64)
+		for {
+			n, err := tty.Read(buf)
+			if err != nil {
+				return
+			}
+			ch <- parseKeys(buf[:n])
+		}
+	}()
+	return ch
+}
+
+func handle(m *model, k keyPress, page int) (string, bool, error) {
+	m.status = ""
+	if k.key == keyInterrupt {
+		return "", true, context.Canceled
+	}
+	if m.typing {
+		handleFilter(m, k)
+		return "", false, nil
+	}
+
+	switch k.key {
+	case keyEscape:
+		if m.filter != "" {
+			m.setFilter("")
+			return "", false, nil
+		}
+		return "", true, nil
+	case keyUp:
+		m.move(-1)
+	case keyDown:
+		m.move(1)
+	case keyLeft:
+		m.collapse()
+	case keyRight:
+		m.expand()
+	case keyEnter:
+		if n := m.selected(); n != nil && !n.isDir() {
+			return n.entry.Path, true, nil
+		}
+		m.toggle()
+	case keyPageUp:
+		m.move(-page / 2)
+	case keyPageDown:
+		m.move(page / 2)
+	case keyHome:
+		m.move(-len(m.rows))
+	case keyEnd:
+		m.move(len(m.rows))
+	case keyRune:
+		return handleRune(m, k.rune, page)
+	}
+	return "", false, nil
+}
+
+func handleRune(m *model, r rune, page int) (string, bool, error) {
+	switch r {
+	case 'k':
+		m.move(-1)
+	case 'j':
+		m.move(1)
+	case 'h':
+		m.collapse()
+	case 'l':
+		m.expand()
+	case ' ':
+		m.toggle()
+	case 'g':
+		m.move(-len(m.rows))
+	case 'G':
+		m.move(len(m.rows))
+	case 'u':
+		m.move(-page / 2)
+	case 'd':
+		m.move(page / 2)
+	case 'm':
+		m.meta = !m.meta
+	case '/':
+		m.typing = true
+	case 'q':
+		if n := m.selected(); n != nil {
+			return n.entry.Path, true, nil
+		}
+		return "", true, nil
+	}
+	return "", false, nil
+}
+
+func handleFilter(m *model, k keyPress) {
+	switch k.key {
+	case keyEnter:
+		m.typing = false
+	case keyEscape:
+		m.typing = false
+		m.setFilter("")
+	case keyBackspace:
+		if r := []rune(m.filter); len(r) > 0 {
+			m.setFilter(string(r[:len(r)-1]))
+		}
+	case keyUp:
+		m.move(-1)
+	case keyDown:
+		m.move(1)
+	case keyRune:
+		m.setFilter(m.filter + string(k.rune))
+	}
+}
@@ -0,0 +1,123 @@
+package browse
+
+import "unicode/utf8"
+
+type key int
+
+const (
+	keyRune key = iota
+	keyUp
+	keyDown
+	keyLeft
+	keyRight
+	keyEnter
+	keyEscape
+	keyBackspace
+	keyPageUp
+	keyPageDown
+	keyHome
+	keyEnd
+	keyInterrupt
+	// keyIgnored stands for escape sequences the browser has no use for,
+	// such as Delete, F1 or modified arrows.
+	keyIgnored
+)
+
+type keyPress struct {
+	key  key
+	rune rune
+}
+
+func parseKeys(buf []byte) []keyPress {
+	var keys []keyPress
+	for len(buf) > 0 {
+		k, n := parseKey(buf)
+		keys = append(keys, k)
+		buf = buf[n:]
+	}
+	return keys
+}
+
+func parseKey(buf []byte) (keyPress, int) {
+	switch buf[0] {
+	case 3:
+		return keyPress{key: keyInterrupt}, 1
+	case 4:
+		return keyPress{key: keyPageDown}, 1
+	case 21:
+		return keyPress{key: keyPageUp}, 1
+	case '\r', '\n':
+		return keyPress{key: keyEnter}, 1
+	case 127, 8:
+		return keyPress{key: keyBackspace}, 1
+	case 27:
+		return parseEscape(buf)
+	}
+
+	r, n := utf8.DecodeRune(buf)
+	return keyPress{key: keyRune, rune: r}, n
+}
+
+// parseEscape reads a sequence starting with ESC. Only a lone ESC is
+// keyEscape; CSI (ESC [) and SS3 (ESC O) sequences are consumed whole and
+// become keyIgnored unless they are a key the browser handles.
+func parseEscape(buf []byte) (keyPress, int) {
+	if len(buf) == 1 {
+		return keyPress{key: keyEscape}, 1
+	}
+
+	switch buf[1] {
+	case '[':
+		n := 2
+		for n < len(buf) && buf[n] >= 0x20 && buf[n] <= 0x3f {
+			n++
+		}
+		if n == len(buf) {
+			return keyPress{key: keyIgnored}, n
+		}
+		return keyPress{key: csiKey(string(buf[2:n]), buf[n])}, n + 1
+	case 'O':
+		if len(buf) < 3 {
+			return keyPress{key: keyIgnored}, 2
+		}
+		return keyPress{key: csiKey("", buf[2])}, 3
+	}
+
+	_, n := utf8.DecodeRune(buf[1:])
+	return keyPress{key: keyIgnored}, 1 + n
+}
+
+func csiKey(params string, final byte) key {
+	if final == '~' {
+		switch params {
+		case "5":
+			return keyPageUp
+		case "6":
+			return keyPageDown
+		case "1", "7":
+			return keyHome
+		case "4", "8":
+			return keyEnd
+		}
+		return keyIgnored
+	}
+	if params != "" && params != "1" {
+		return keyIgnored
+	}
+
+	switch final {
+	case 'A':
+		return keyUp
+	case 'B':
+		return keyDown
+	case 'C':
+		return keyRight
+	case 'D':
+		return keyLeft
+	case 'H':
+		return keyHome
+	case 'F':
+		return keyEnd
+	}
+	return keyIgnored
+}
@@ -0,0 +1,35 @@
+package browse
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseKeys(t *testing.T) {
+	cases := []struct {
+		in   string
+		want []key
+	}{
+		{"\x1b", []key{keyEscape}},
+		{"\x1b[A\x1b[B\x1bOC\x1bOD", []key{keyUp, keyDown, keyRight, keyLeft}},
+		{"\x1b[5~\x1b[6~\x1b[H\x1b[4~", []key{keyPageUp, keyPageDown, keyHome, keyEnd}},
+		{"\x1b[3~", []key{keyIgnored}},
+		{"\x1b[2~", []key{keyIgnored}},
+		{"\x1bOP", []key{keyIgnored}},
+		{"\x1b[15~", []key{keyIgnored}},
+		{"\x1b[1;5A", []key{keyIgnored}},
+		{"\x1b[3~q", []key{keyIgnored, keyRune}},
+		{"\x1bx", []key{keyIgnored}},
+		{"\x1b[", []key{keyIgnored}},
+	}
+
+	for _, c := range cases {
+		var got []key
+		for _, k := range parseKeys([]byte(c.in)) {
+			got = append(got, k.key)
+		}
+		if !slices.Equal(got, c.want) {
+			t.Errorf("%q: got %v, want %v", c.in, got, c.want)
+		}
+	}
+}
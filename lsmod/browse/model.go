@@ -0,0 +1,222 @@
+package browse
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+
+	"github.com/ymatsukawa/lsmod/finder"
+)
+
+type node struct {
+	entry    finder.Entry
+	parent   *node
+	depth    int
+	children []*node
+	loaded   bool
+	expanded bool
+	err      error
+}
+
+func (n *node) isDir() bool {
+	return n.entry.Err == nil && (n.entry.IsDir || n.entry.TargetIsDir)
+}
+
+type model struct {
+	ctx    context.Context
+	opts   finder.Options
+	root   *node
+	rows   []*node
+	cursor int
+	offset int
+	filter string
+	typing bool
+	meta   bool
+	status string
+}
+
+func newModel(ctx context.Context, path string, opts finder.Options) (*model, error) {
+	opts.MaxDepth = 1
+	opts.Sizes = false
+	opts.MaxEntries = 0
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &model{ctx: ctx, opts: opts}
+	tree, err := finder.Tree(ctx, abs, opts)
+	if err != nil {
+		return nil, err
+	}
+	tree.Name = abs
+	m.root = &node{entry: tree.Entry}
+	m.fill(m.root, tree)
+	m.root.expanded = true
+	m.refresh()
+	return m, nil
+}
+
+func (m *model) fill(n *node, tree finder.TreeNode) {
+	n.loaded = true
+	n.children = make([]*node, 0, len(tree.Children))
+	for _, child := range tree.Children {
+		if child.Collapsed() {
+			continue
+		}
+		n.children = append(n.children, &node{entry: child.Entry, parent: n, depth: n.depth + 1})
+	}
+}
+
+func (m *model) load(n *node) {
+	if n.loaded {
+		return
+	}
+	tree, err := finder.Tree(m.ctx, n.entry.Path, m.opts)
+	if err != nil {
+		n.err = err
+		m.status = err.Error()
+		return
+	}
+	m.fill(n, tree)
+	if count := tree.ErrorCount(); count > 0 {
+		m.status = "some entries could not be read"
+	}
+}
+
+func (m *model) selected() *node {
+	if m.cursor < 0 || m.cursor >= len(m.rows) {
+		return nil
+	}
+	return m.rows[m.cursor]
+}
+
+func (m *model) refresh() {
+	current := m.selected()
+	m.rows = m.rows[:0]
+	m.collect(m.root)
+
+	m.cursor = 0
+	for i, n := range m.rows {
+		if n == current {
+			m.cursor = i
+			break
+		}
+	}
+}
+
+func (m *model) collect(n *node) {
+	if n != m.root && !m.visible(n) {
+		return
+	}
+	m.rows = append(m.rows, n)
+	if !n.expanded && m.filter == "" {
+		return
+	}
+	if !n.expanded && !m.hasMatch(n) {
+		return
+	}
+	for _, child := range n.children {
+		m.collect(child)
+	}
+}
+
+func (m *model) visible(n *node) bool {
+	return m.filter == "" || m.matches(n) || m.hasMatch(n)
+}
+
+func (m *model) matches(n *node) bool {
+	return strings.Contains(strings.ToLower(n.entry.Name), strings.ToLower(m.filter))
+}
+
+func (m *model) hasMatch(n *node) bool {
+	for _, child := range n.children {
+		if m.matches(child) || m.hasMatch(child) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *model) move(delta int) {
+	m.cursor = max(0, min(len(m.rows)-1, m.cursor+delta))
+}
+
+func (m *model) expand() {
+	n := m.selected()
+	if n == nil || !n.isDir() {
+		return
+	}
+	if n.expanded {
+		if len(n.children) > 0 {
+			m.move(1)
+		}
+		return
+	}
+	m.load(n)
+	n.expanded = n.err == nil
+	m.refresh()
+}
+
+func (m *model) collapse() {
+	n := m.selected()
+	if n == nil {
+		return
+	}
+	if n.expanded && n != m.root {
+		n.expanded = false
+		m.refresh()
+		return
+	}
+	if n.parent != nil {
+		m.focus(n.parent)
+	}
+}
+
+func (m *model) toggle() {
+	n := m.selected()
+	if n == nil {
+		return
+	}
+	if n.expanded {
+		m.collapse()
+		return
+	}
+	m.expand()
+}
+
+func (m *model) focus(target *node) {
+	for i, n := range m.rows {
+		if n == target {
+			m.cursor = i
+			return
+		}
+	}
+}
+
+func (m *model) setFilter(filter string) {
+	m.filter = filter
+	m.refresh()
+	if m.filter == "" {
+		return
+	}
+	if n := m.selected(); n != nil && !m.matches(n) {
+		for i, row := range m.rows {
+			if m.matches(row) {
+				m.cursor = i
+				break
+			}
+		}
+	}
+}
+
+func (m *model) scroll(height int) {
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if m.cursor >= m.offset+height {
+		m.offset = m.cursor - height + 1
+	}
+	m.offset = max(0, min(m.offset, len(m.rows)-height))
+}
@@ -0,0 +1,63 @@
+package browse
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+type terminal struct {
+	tty   *os.File
+	saved syscall.Termios
+}
+
+func openTerminal() (*terminal, error) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return nil, fmt.Errorf("open terminal: %w", err)
+	}
+
+	t := &terminal{tty: tty}
+	if err := t.ioctl(syscall.TCGETS, unsafe.Pointer(&t.saved)); err != nil {
+		tty.Close()
+		return nil, fmt.Errorf("read terminal mode: %w", err)
+	}
+
+	raw := t.saved
+	raw.Iflag &^= syscall.BRKINT | syscall.ICRNL | syscall.INPCK | syscall.ISTRIP | syscall.IXON
+	raw.Oflag &^= syscall.OPOST
+	raw.Cflag |= syscall.CS8
+	raw.Lflag &^= syscall.ECHO | syscall.ICANON | syscall.IEXTEN | syscall.ISIG
+	raw.Cc[syscall.VMIN] = 1
+	raw.Cc[syscall.VTIME] = 0
+	if err := t.ioctl(syscall.TCSETS, unsafe.Pointer(&raw)); err != nil {
+		tty.Close()
+		return nil, fmt.Errorf("set raw mode: %w", err)
+	}
+	return t, nil
+}
+
+func (t *terminal) size() (width, height int) {
+	var ws struct{ Row, Col, X, Y uint16 }
+	if err := t.ioctl(syscall.TIOCGWINSZ, unsafe.Pointer(&ws)); err != nil || ws.Col == 0 {
+		return 80, 24
+	}
+	return int(ws.Col), int(ws.Row)
+}
+
+func (t *terminal) restore() error {
+	err := t.ioctl(syscall.TCSETS, unsafe.Pointer(&t.saved))
+	if cerr := t.tty.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func (t *terminal) ioctl(req uintptr, arg unsafe.Pointer) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, t.tty.Fd(), req, uintptr(arg))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
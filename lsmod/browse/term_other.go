@@ -0,0 +1,24 @@
+//go:build !linux
+
+package browse
+
+import (
+	"errors"
+	"os"
+)
+
+type terminal struct {
+	tty *os.File
+}
+
+func openTerminal() (*terminal, error) {
+	return nil, errors.New("browse is only supported on Linux terminals")
+}
+
+func (t *terminal) size() (width, height int) {
+	return 80, 24
+}
+
+func (t *terminal) restore() error {
+	return nil
+}
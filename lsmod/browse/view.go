@@ -0,0 +1,114 @@
+package browse
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ymatsukawa/lsmod/formatter"
+)
+
+const (
+	clearScreen = "\033[H\033[2J"
+	clearLine   = "\033[K"
+	reverse     = "\033[7m"
+	dim         = "\033[2m"
+	reset       = "\033[0m"
+)
+
+type view struct {
+	colors *formatter.Palette
+	size   formatter.SizeStyle
+}
+
+func (v view) render(w io.Writer, m *model, width, height int) error {
+	listHeight := max(1, height-1)
+	m.scroll(listHeight)
+
+	var b strings.Builder
+	b.WriteString("\033[H")
+	for i := 0; i < listHeight; i++ {
+		row := m.offset + i
+		if row < len(m.rows) {
+			v.line(&b, m, m.rows[row], row == m.cursor, width)
+		}
+		b.WriteString(clearLine + "\r\n")
+	}
+	v.statusLine(&b, m, width)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func (v view) line(b *strings.Builder, m *model, n *node, selected bool, width int) {
+	prefix := strings.Repeat("  ", n.depth)
+	switch {
+	case !n.isDir():
+		prefix += "  "
+	case n.expanded:
+		prefix += "▾ "
+	default:
+		prefix += "▸ "
+	}
+
+	meta := ""
+	if m.meta {
+		meta = v.metadata(n)
+	}
+
+	name := n.entry.Name
+	if n.entry.IsLink() {
+		name += " -> " + n.entry.LinkTarget
+	}
+	room := width - utf8.RuneCountInString(prefix) - utf8.RuneCountInString(meta)
+	name = truncate(name, room)
+
+	if selected {
+		b.WriteString(reverse + meta + prefix + name + reset)
+		return
+	}
+	b.WriteString(dim + meta + reset + prefix)
+	if name == n.entry.Name {
+		name = v.colors.Paint(n.entry, name)
+	}
+	b.WriteString(name)
+}
+
+func (v view) metadata(n *node) string {
+	e := n.entry
+	if e.Err != nil {
+		return fmt.Sprintf("%-10s %8s %16s  ", "??????????", "?", "?")
+	}
+	return fmt.Sprintf("%-10s %8s %16s  ", e.Mode,
+		formatter.FormatSize(e.Size, v.size), e.ModTime.Format("2006-01-02 15:04"))
+}
+
+func (v view) statusLine(b *strings.Builder, m *model, width int) {
+	var text string
+	switch {
+	case m.typing:
+		text = "/" + m.filter
+	case m.status != "":
+		text = m.status
+	case m.selected() != nil:
+		text = m.selected().entry.Path
+		if m.filter != "" {
+			text += "  [filter: " + m.filter + "]"
+		}
+	}
+	b.WriteString(reverse + truncate(text, width))
+	b.WriteString(strings.Repeat(" ", max(0, width-utf8.RuneCountInString(text))))
+	b.WriteString(reset + clearLine)
+}
+
+func truncate(s string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	if utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:width-1]) + "…"
+}
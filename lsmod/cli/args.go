@@ -1,8 +1,82 @@
 package cli
 
-func pathArg(args []string) string {
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/ymatsukawa/lsmod/formatter"
+)
+
+func pathArgs(args []string) []string {
 	if len(args) > 0 {
-		return args[0]
+		return args
+	}
+	return []string{"."}
+}
+
+func readPathList(r io.Reader, nul bool) ([]string, error) {
+	sep := byte('\n')
+	if nul {
+		sep = 0
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		if i := bytes.IndexByte(data, sep); i >= 0 {
+			return i + 1, data[:i], nil
+		}
+		if atEOF && len(data) > 0 {
+			return len(data), data, nil
+		}
+		return 0, nil, nil
+	})
+
+	var paths []string
+	for scanner.Scan() {
+		path := string(bytes.TrimSuffix(scanner.Bytes(), []byte{'\r'}))
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read path list: %w", err)
+	}
+	return paths, nil
+}
+
+func eachPath(cmd *cobra.Command, paths []string, enc formatter.Encoder,
+	fn func(path string, enc formatter.Encoder) (int, error)) error {
+	if len(paths) > 1 && output == formatter.OutputJSON {
+		return fmt.Errorf("--output json takes a single path, use --output ndjson to list several")
+	}
+
+	failed := 0
+	for i, path := range paths {
+		if len(paths) > 1 && output == formatter.OutputText && !print0 {
+			if i > 0 {
+				fmt.Fprintln(os.Stdout)
+			}
+			fmt.Fprintf(os.Stdout, "%s:\n", path)
+		}
+
+		pathEnc := enc
+		if len(paths) > 1 {
+			pathEnc = formatter.ForRoot(enc, path)
+		}
+		n, err := fn(path, pathEnc)
+		if err != nil {
+			if len(paths) == 1 || cmd.Context().Err() != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "%s: %v\n", cmd.Root().Name(), err)
+			n = 1
+		}
+		failed += n
 	}
-	return "."
+	return partialError(cmd, failed)
 }
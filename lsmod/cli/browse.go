@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/ymatsukawa/lsmod/browse"
+	"github.com/ymatsukawa/lsmod/formatter"
+)
+
+var browseCommand = &cobra.Command{
+	Use:   "browse [path]",
+	Short: "browse directories interactively",
+	Long:  "browse directories in a full-screen terminal UI and print the selected path on exit",
+	Args:  cobra.MaximumNArgs(1),
+	RunE:  runBrowse,
+}
+
+func init() {
+	addColorFlag(browseCommand)
+	addSortFlags(browseCommand)
+	addFilterFlags(browseCommand)
+	addSizeFlags(browseCommand)
+	browseCommand.Flags().BoolVarP(&follow, "follow", "l", false, "descend into symlinked directories")
+}
+
+func runBrowse(cmd *cobra.Command, args []string) error {
+	mode, err := formatter.ParseColorMode(color)
+	if err != nil {
+		return err
+	}
+
+	opts, err := finderOptions()
+	if err != nil {
+		return err
+	}
+
+	selected, err := browse.Run(cmd.Context(), pathArgs(args)[0], browse.Options{
+		Finder: opts,
+		Color:  mode,
+		Size:   sizeStyle(),
+	})
+	if err != nil {
+		return err
+	}
+	if selected != "" {
+		fmt.Fprintln(os.Stdout, selected)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type config struct {
+	Formats map[string]string `json:"formats"`
+}
+
+func loadConfig() (config, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return config{}, nil
+	}
+
+	path := filepath.Join(dir, "lsmod", "config.json")
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return config{}, nil
+	}
+	if err != nil {
+		return config{}, fmt.Errorf("read config: %w", err)
+	}
+
+	var cfg config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return config{}, fmt.Errorf("parse config %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+func resolveFormat(name string) (string, error) {
+	if name == "" {
+		return "", nil
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return "", err
+	}
+	if text, ok := cfg.Formats[name]; ok {
+		return text, nil
+	}
+	if !strings.Contains(name, "{{") {
+		return "", fmt.Errorf("unknown format %q (not a template and not defined in the config file)", name)
+	}
+	return name, nil
+}
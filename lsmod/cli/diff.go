@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/ymatsukawa/lsmod/diff"
+	"github.com/ymatsukawa/lsmod/formatter"
+)
+
+var (
+	compareFields []string
+	content       bool
+	showAll       bool
+)
+
+var diffCommand = &cobra.Command{
+	Use:   "diff <old> <new>",
+	Short: "compare two directory trees",
+	Long:  "compare two directory trees and print the added, removed and changed entries as a merged tree",
+	Args:  cobra.ExactArgs(2),
+	RunE:  runDiff,
+}
+
+func init() {
+	addOutputFlag(diffCommand)
+	addFilterFlags(diffCommand)
+	addWalkFlags(diffCommand)
+	addSizeFlags(diffCommand)
+	addCompareFlags(diffCommand)
+}
+
+func addCompareFlags(cmd *cobra.Command) {
+	cmd.Flags().StringSliceVar(&compareFields, "compare", diff.DefaultFields.Names(),
+		"fields to compare: type, size, mode, owner, mtime, content")
+	cmd.Flags().BoolVar(&content, "content", false, "also compare file contents by SHA-256")
+	cmd.Flags().BoolVar(&showAll, "all", false, "show unchanged entries too")
+}
+
+func diffOptions() (diff.Options, error) {
+	fields, err := diff.ParseFields(compareFields)
+	if err != nil {
+		return diff.Options{}, err
+	}
+	if content {
+		fields |= diff.FieldContent
+	}
+
+	opts, err := finderOptions()
+	if err != nil {
+		return diff.Options{}, err
+	}
+	return diff.Options{Finder: opts, Fields: fields}, nil
+}
+
+func runDiff(cmd *cobra.Command, args []string) error {
+	formatOpts, err := formatOptions()
+	if err != nil {
+		return err
+	}
+	formatOpts.TimeStyle = formatter.TimeStyleFullISO
+
+	enc, err := formatter.NewEncoder(output, formatOpts)
+	if err != nil {
+		return err
+	}
+
+	opts, err := diffOptions()
+	if err != nil {
+		return err
+	}
+
+	node, err := diff.Compare(cmd.Context(), args[0], args[1], opts)
+	if err != nil {
+		return fmt.Errorf("diff %s %s: %w", args[0], args[1], err)
+	}
+	if !showAll {
+		node = node.Prune()
+	}
+	if err := enc.EncodeDiff(os.Stdout, node); err != nil {
+		return err
+	}
+	return diffResult(cmd, node)
+}
+
+func diffResult(cmd *cobra.Command, node diff.Node) error {
+	if err := partialError(cmd, node.ErrorCount()); err != nil {
+		return err
+	}
+	if !node.Dirty() {
+		return nil
+	}
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+	return &exitError{code: exitDiffer, err: errors.New("trees differ")}
+}
@@ -0,0 +1,35 @@
+package cli
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var duCommand = &cobra.Command{
+	Use:   "du [path...]",
+	Short: "display directory sizes",
+	Long:  "display directory tree with recursive sizes, largest first",
+	Args:  cobra.ArbitraryArgs,
+	RunE:  runDu,
+}
+
+func init() {
+	addOutputFlag(duCommand)
+	addSortFlags(duCommand)
+	addFilterFlags(duCommand)
+	addTreeFlags(duCommand)
+	addWalkFlags(duCommand)
+	addUsageFlags(duCommand)
+	addStdinFlags(duCommand)
+	addTypeFlags(duCommand)
+}
+
+func runDu(cmd *cobra.Command, args []string) error {
+	sizes = true
+	if !cmd.Flags().Changed("sort") {
+		sortBy = "size"
+	}
+	if !cmd.Flags().Changed("dirs-first") {
+		dirsFirst = false
+	}
+	return runTree(cmd, args)
+}
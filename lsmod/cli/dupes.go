@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/ymatsukawa/lsmod/dupes"
+	"github.com/ymatsukawa/lsmod/formatter"
+)
+
+var hardlinks bool
+
+var dupesCommand = &cobra.Command{
+	Use:   "dupes [path]",
+	Short: "find files with identical content",
+	Long:  "find files with identical content and show how many bytes the copies waste",
+	Args:  cobra.MaximumNArgs(1),
+	RunE:  runDupes,
+}
+
+func init() {
+	addOutputFlag(dupesCommand)
+	addFilterFlags(dupesCommand)
+	addWalkFlags(dupesCommand)
+	addSizeFlags(dupesCommand)
+	dupesCommand.Flags().IntVarP(&maxDepth, "depth", "L", 0, "descend at most N levels (0 for no limit)")
+	dupesCommand.Flags().BoolVar(&hardlinks, "hardlinks", false, "ignore files that are already hard links of each other")
+}
+
+func runDupes(cmd *cobra.Command, args []string) error {
+	formatOpts, err := formatOptions()
+	if err != nil {
+		return err
+	}
+
+	enc, err := formatter.NewEncoder(output, formatOpts)
+	if err != nil {
+		return err
+	}
+
+	opts, err := finderOptions()
+	if err != nil {
+		return err
+	}
+
+	path := pathArgs(args)[0]
+	result, err := dupes.Find(cmd.Context(), path, dupes.Options{Finder: opts, Hardlinks: hardlinks})
+	if err != nil {
+		return fmt.Errorf("dupes %s: %w", path, err)
+	}
+	if err := enc.EncodeDupes(os.Stdout, result); err != nil {
+		return err
+	}
+	return partialError(cmd, result.Failed)
+}
@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	exitFailure     = 1
+	exitPartial     = 2
+	exitDiffer      = 3
+	exitInterrupted = 130
+)
+
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string {
+	return e.err.Error()
+}
+
+func (e *exitError) Unwrap() error {
+	return e.err
+}
+
+func ExitCode(err error) int {
+	var exitErr *exitError
+	if errors.As(err, &exitErr) {
+		return exitErr.code
+	}
+	if errors.Is(err, context.Canceled) {
+		return exitInterrupted
+	}
+	return exitFailure
+}
+
+func partialError(cmd *cobra.Command, failed int) error {
+	if failed == 0 {
+		return nil
+	}
+	cmd.SilenceUsage = true
+	noun := "entries"
+	if failed == 1 {
+		noun = "entry"
+	}
+	return &exitError{
+		code: exitPartial,
+		err:  fmt.Errorf("%d %s could not be read", failed, noun),
+	}
+}
@@ -0,0 +1,93 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/ymatsukawa/lsmod/formatter"
+	"github.com/ymatsukawa/lsmod/query"
+)
+
+var (
+	findTree bool
+	print0   bool
+)
+
+var findCommand = &cobra.Command{
+	Use:   "find <expression> [path...]",
+	Short: "find entries matching an expression",
+	Long:  "find files and directories whose metadata matches an expression such as 'size>10M and mtime<7d'",
+	Args:  cobra.MinimumNArgs(1),
+	RunE:  runFind,
+}
+
+func init() {
+	addOutputFlag(findCommand)
+	addSortFlags(findCommand)
+	addFilterFlags(findCommand)
+	addWalkFlags(findCommand)
+	addSizeFlags(findCommand)
+	addTypeFlags(findCommand)
+	addTimeFlags(findCommand)
+	findCommand.Flags().IntVarP(&maxDepth, "depth", "L", 0, "descend at most N levels (0 for no limit)")
+	findCommand.Flags().BoolVarP(&follow, "follow", "l", false,
+		"descend into symlinked directories, skipping loops")
+	findCommand.Flags().BoolVarP(&inode, "inode", "i", false,
+		"print the inode number of each entry")
+	findCommand.Flags().BoolVar(&findTree, "tree", false,
+		"show matches as a tree together with their parent directories")
+	findCommand.Flags().BoolVarP(&print0, "print0", "0", false,
+		"print matching paths separated by NUL (for xargs -0)")
+}
+
+func runFind(cmd *cobra.Command, args []string) error {
+	if print0 && (findTree || output != formatter.OutputText) {
+		return fmt.Errorf("--print0 cannot be combined with --tree or --output")
+	}
+
+	q, err := query.Parse(args[0], time.Now())
+	if err != nil {
+		return fmt.Errorf("parse expression: %w", err)
+	}
+
+	formatOpts, err := formatOptions()
+	if err != nil {
+		return err
+	}
+
+	enc, err := formatter.NewEncoder(output, formatOpts)
+	if err != nil {
+		return err
+	}
+
+	opts, err := finderOptions()
+	if err != nil {
+		return err
+	}
+
+	return eachPath(cmd, pathArgs(args[1:]), enc, func(path string, enc formatter.Encoder) (int, error) {
+		result, err := query.Find(cmd.Context(), path, q, opts)
+		if err != nil {
+			return 0, fmt.Errorf("find %s: %w", path, err)
+		}
+
+		switch {
+		case print0:
+			for _, m := range result.Matches {
+				if _, err := fmt.Fprintf(os.Stdout, "%s\x00", m.Name); err != nil {
+					return 0, err
+				}
+			}
+		case findTree:
+			err = enc.EncodeTree(os.Stdout, result.Tree)
+		default:
+			err = enc.EncodeEntries(os.Stdout, result.Matches)
+		}
+		if err != nil {
+			return 0, err
+		}
+		return result.Failed, nil
+	})
+}
@@ -0,0 +1,261 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/ymatsukawa/lsmod/finder"
+	"github.com/ymatsukawa/lsmod/formatter"
+)
+
+var (
+	output     string
+	color      string
+	human      bool
+	si         bool
+	inode      bool
+	sortBy     string
+	reverse    bool
+	dirsFirst  bool
+	maxDepth   int
+	dirsOnly   bool
+	maxEntries int
+	gitIgnore  bool
+	include    []string
+	exclude    []string
+	strict     bool
+	follow     bool
+	jobs       int
+	git        bool
+	sizes      bool
+	bars       bool
+	allocated  bool
+	fromStdin  bool
+	nullSep    bool
+	xattr      bool
+	format     string
+	timeField  string
+	timeStyle  string
+	utc        bool
+	tz         string
+	sniffType  bool
+	typeFilter []string
+	nested     bool
+	columns    bool
+	export     string
+)
+
+func addOutputFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&output, "output", formatter.OutputText,
+		"output format: text, json or ndjson")
+	addColorFlag(cmd)
+}
+
+func addColorFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&color, "color", "auto", "colorize names: auto, always or never")
+	cmd.Flags().Lookup("color").NoOptDefVal = "always"
+}
+
+func addSortFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&sortBy, "sort", "name",
+		"sort by name, natural, mtime, size, ext or none")
+	cmd.Flags().BoolVarP(&reverse, "reverse", "r", false, "reverse the sort order")
+	cmd.Flags().BoolVar(&dirsFirst, "dirs-first", true, "list directories before files")
+}
+
+func addFilterFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&gitIgnore, "gitignore", false,
+		"hide entries ignored by .gitignore, .ignore, .lsmodignore and git excludes")
+	cmd.Flags().StringSliceVar(&include, "include", nil, "only show files matching the glob (repeatable)")
+	cmd.Flags().StringSliceVar(&exclude, "exclude", nil, "hide entries matching the glob (repeatable)")
+}
+
+func addStdinFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&fromStdin, "from-stdin", false, "read the paths to show from stdin instead of walking")
+	cmd.Flags().BoolVarP(&nullSep, "null", "0", false, "paths on stdin are NUL-separated (e.g. git ls-files -z)")
+}
+
+func addTypeFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&sniffType, "type", false, "show the MIME type detected from the first bytes of each file")
+	cmd.Flags().StringSliceVar(&typeFilter, "type-filter", nil,
+		"only show files of a type: binary, text, executable, script, image, archive or a MIME glob like image/*")
+}
+
+func addWalkFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&strict, "strict", false, "abort on the first unreadable entry")
+	cmd.Flags().IntVarP(&jobs, "jobs", "j", 0, "number of directories read in parallel (0 for auto)")
+	cmd.Flags().BoolVar(&git, "git", false, "show the git status of each entry")
+	cmd.Flags().BoolVar(&nested, "nested", false, "open archives found inside archives as directories")
+}
+
+func addTreeFlags(cmd *cobra.Command) {
+	cmd.Flags().IntVarP(&maxDepth, "depth", "L", 0, "descend at most N levels (0 for no limit)")
+	cmd.Flags().BoolVarP(&dirsOnly, "dirs-only", "d", false, "list directories only")
+	cmd.Flags().IntVar(&maxEntries, "max-entries", 0,
+		"show at most N entries per directory and collapse the rest (0 for no limit)")
+	cmd.Flags().BoolVarP(&follow, "follow", "l", false,
+		"descend into symlinked directories, skipping loops")
+	cmd.Flags().BoolVar(&sizes, "sizes", false,
+		"show the recursive size and file count of every entry")
+}
+
+func addSizeFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("help", false, "help for "+cmd.Name())
+	cmd.Flags().BoolVarP(&human, "human-readable", "h", false,
+		"print sizes like 1.5KiB and 23MiB")
+	cmd.Flags().BoolVar(&si, "si", false,
+		"like --human-readable, but use powers of 1000 (kB, MB)")
+}
+
+func addUsageFlags(cmd *cobra.Command) {
+	addSizeFlags(cmd)
+	cmd.Flags().BoolVar(&bars, "bars", false, "draw a bar proportional to the parent directory size")
+	cmd.Flags().BoolVar(&allocated, "allocated", false, "use allocated blocks instead of apparent size")
+}
+
+func addListFlags(cmd *cobra.Command) {
+	addOutputFlag(cmd)
+	addSortFlags(cmd)
+	addFilterFlags(cmd)
+	addWalkFlags(cmd)
+	addStdinFlags(cmd)
+	addTypeFlags(cmd)
+	addSizeFlags(cmd)
+	cmd.Flags().BoolVarP(&inode, "inode", "i", false,
+		"print the inode number of each entry")
+	cmd.Flags().BoolVarP(&columns, "columns", "C", false,
+		"list names in columns fitted to the terminal width (one per line when piped)")
+	cmd.Flags().BoolVarP(&xattr, "xattr", "@", false,
+		"list extended attribute names, file capabilities and the SELinux label")
+	cmd.Flags().StringVar(&format, "format", "",
+		"print each entry with a Go template, or a format name from the config file")
+	addTimeFlags(cmd)
+}
+
+func addTimeFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&timeField, "time", "mtime",
+		"time to show and sort by: mtime, atime, ctime or birth")
+	cmd.Flags().StringVar(&timeStyle, "time-style", formatter.TimeStyleLongISO,
+		"long-iso, iso, full-iso, relative, unix or +LAYOUT (Go time layout)")
+	cmd.Flags().BoolVar(&utc, "utc", false, "show times in UTC")
+	cmd.Flags().StringVar(&tz, "tz", "", "show times in the given IANA time zone (e.g. Asia/Tokyo)")
+}
+
+func formatOptions() (formatter.Options, error) {
+	mode, err := formatter.ParseColorMode(color)
+	if err != nil {
+		return formatter.Options{}, err
+	}
+
+	tmpl, err := resolveFormat(format)
+	if err != nil {
+		return formatter.Options{}, err
+	}
+
+	field, err := parseTimeField()
+	if err != nil {
+		return formatter.Options{}, err
+	}
+	if timeStyle != "" {
+		if err := formatter.CheckTimeStyle(timeStyle); err != nil {
+			return formatter.Options{}, err
+		}
+	}
+	loc, err := timeLocation()
+	if err != nil {
+		return formatter.Options{}, err
+	}
+
+	opts := formatter.Options{
+		Size:      sizeStyle(),
+		Inode:     inode,
+		Colors:    formatter.NewPalette(mode, os.Stdout),
+		Git:       git,
+		Bars:      bars,
+		Allocated: allocated,
+		Xattr:     xattr,
+		Format:    tmpl,
+		Time:      field,
+		MIME:      sniffType,
+		TimeStyle: timeStyle,
+		Location:  loc,
+		Grid:      columns,
+		Export:    export,
+	}
+	if columns {
+		opts.Width = formatter.TerminalWidth(os.Stdout)
+	}
+	return opts, nil
+}
+
+func sizeStyle() formatter.SizeStyle {
+	switch {
+	case si:
+		return formatter.SizeSI
+	case human:
+		return formatter.SizeIEC
+	}
+	return formatter.SizeBytes
+}
+
+func parseTimeField() (finder.TimeField, error) {
+	if timeField == "" {
+		return finder.TimeMtime, nil
+	}
+	return finder.ParseTimeField(timeField)
+}
+
+func timeLocation() (*time.Location, error) {
+	switch {
+	case utc && tz != "":
+		return nil, fmt.Errorf("--utc and --tz cannot be used together")
+	case utc:
+		return time.UTC, nil
+	case tz != "":
+		loc, err := time.LoadLocation(tz)
+		if err != nil {
+			return nil, fmt.Errorf("load time zone: %w", err)
+		}
+		return loc, nil
+	}
+	return nil, nil
+}
+
+func finderOptions() (finder.Options, error) {
+	by, err := finder.ParseSortBy(sortBy)
+	if err != nil {
+		return finder.Options{}, err
+	}
+
+	field, err := parseTimeField()
+	if err != nil {
+		return finder.Options{}, err
+	}
+	if err := finder.CheckTypeFilter(typeFilter); err != nil {
+		return finder.Options{}, err
+	}
+
+	return finder.Options{
+		Sort:           by,
+		Reverse:        reverse,
+		DirsFirst:      dirsFirst,
+		MaxDepth:       maxDepth,
+		DirsOnly:       dirsOnly,
+		MaxEntries:     maxEntries,
+		GitIgnore:      gitIgnore,
+		Include:        include,
+		Exclude:        exclude,
+		Strict:         strict,
+		Follow:         follow,
+		Workers:        jobs,
+		Git:            git,
+		Sizes:          sizes,
+		Allocated:      allocated,
+		Time:           field,
+		Sniff:          sniffType,
+		TypeFilter:     typeFilter,
+		NestedArchives: nested,
+	}, nil
+}
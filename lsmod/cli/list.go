@@ -10,20 +10,61 @@ import (
 )
 
 var listCommand = &cobra.Command{
-	Use:   "l [path]",
+	Use:   "l [path...]",
 	Short: "list up",
 	Long:  "list up files and directories like ls -al",
-	Args:  cobra.MaximumNArgs(1),
+	Args:  cobra.ArbitraryArgs,
 	RunE:  runList,
 }
 
+func init() {
+	addListFlags(rootCmd)
+	addListFlags(listCommand)
+}
+
 func runList(cmd *cobra.Command, args []string) error {
-	path := pathArg(args)
+	formatOpts, err := formatOptions()
+	if err != nil {
+		return err
+	}
 
-	entries, err := finder.Find(path)
+	enc, err := formatter.NewEncoder(output, formatOpts)
 	if err != nil {
-		return fmt.Errorf("find %s: %w", path, err)
+		return err
+	}
+
+	opts, err := finderOptions()
+	if err != nil {
+		return err
+	}
+	// The long format marks ACLs and xattrs in the mode column, which only
+	// needs their names; -@, JSON and templates also show their values.
+	opts.Xattrs = xattr || !columns
+	opts.XattrValues = xattr || output != formatter.OutputText || format != ""
+
+	if fromStdin {
+		paths, err := readPathList(os.Stdin, nullSep)
+		if err != nil {
+			return err
+		}
+		entries, err := finder.EntriesFromPaths(cmd.Context(), paths, opts)
+		if err != nil {
+			return err
+		}
+		if err := enc.EncodeEntries(os.Stdout, entries); err != nil {
+			return err
+		}
+		return partialError(cmd, finder.CountErrors(entries))
 	}
 
-	return formatter.Print(os.Stdout, entries)
+	return eachPath(cmd, pathArgs(args), enc, func(path string, enc formatter.Encoder) (int, error) {
+		entries, err := finder.Find(cmd.Context(), path, opts)
+		if err != nil {
+			return 0, fmt.Errorf("find %s: %w", path, err)
+		}
+		if err := enc.EncodeEntries(os.Stdout, entries); err != nil {
+			return 0, err
+		}
+		return finder.CountErrors(entries), nil
+	})
 }
@@ -1,22 +1,36 @@
 package cli
 
 import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
 	"github.com/spf13/cobra"
 )
 
 var rootCmd = &cobra.Command{
-	Use:   "lsmod [path]",
+	Use:   "lsmod [path...]",
 	Short: "ls modified",
 	Long:  `ls modified.`,
-	Args:  cobra.MaximumNArgs(1),
+	Args:  cobra.ArbitraryArgs,
 	RunE:  runList,
 }
 
 func Execute() error {
-	return rootCmd.Execute()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return rootCmd.ExecuteContext(ctx)
 }
 
 func init() {
 	rootCmd.AddCommand(listCommand)
 	rootCmd.AddCommand(treeCommand)
+	rootCmd.AddCommand(duCommand)
+	rootCmd.AddCommand(browseCommand)
+	rootCmd.AddCommand(watchCommand)
+	rootCmd.AddCommand(diffCommand)
+	rootCmd.AddCommand(snapshotCommand)
+	rootCmd.AddCommand(dupesCommand)
+	rootCmd.AddCommand(findCommand)
 }
@@ -0,0 +1,146 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/ymatsukawa/lsmod/diff"
+	"github.com/ymatsukawa/lsmod/finder"
+	"github.com/ymatsukawa/lsmod/formatter"
+	"github.com/ymatsukawa/lsmod/snapshot"
+)
+
+var (
+	manifestOut string
+	checksums   bool
+)
+
+var snapshotCommand = &cobra.Command{
+	Use:   "snapshot",
+	Short: "record a tree manifest and check against it later",
+}
+
+var snapshotSaveCommand = &cobra.Command{
+	Use:   "save <path>",
+	Short: "write a manifest of a directory tree",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runSnapshotSave,
+}
+
+var snapshotCheckCommand = &cobra.Command{
+	Use:   "check <manifest> <path>",
+	Short: "compare a directory tree with a manifest",
+	Args:  cobra.ExactArgs(2),
+	RunE:  runSnapshotCheck,
+}
+
+func init() {
+	snapshotSaveCommand.Flags().StringVarP(&manifestOut, "out", "o", "", "write the manifest to a file instead of stdout")
+	snapshotSaveCommand.Flags().BoolVar(&checksums, "checksum", false, "record the SHA-256 of every regular file")
+	addFilterFlags(snapshotSaveCommand)
+	addWalkFlags(snapshotSaveCommand)
+
+	addOutputFlag(snapshotCheckCommand)
+	addFilterFlags(snapshotCheckCommand)
+	addWalkFlags(snapshotCheckCommand)
+	addSizeFlags(snapshotCheckCommand)
+	addCompareFlags(snapshotCheckCommand)
+
+	snapshotCommand.AddCommand(snapshotSaveCommand)
+	snapshotCommand.AddCommand(snapshotCheckCommand)
+}
+
+func runSnapshotSave(cmd *cobra.Command, args []string) error {
+	opts, err := finderOptions()
+	if err != nil {
+		return err
+	}
+
+	m, failed, err := snapshot.Save(cmd.Context(), args[0], snapshot.Options{Finder: opts, Checksums: checksums})
+	if err != nil {
+		return fmt.Errorf("snapshot %s: %w", args[0], err)
+	}
+
+	if err := writeManifest(m, manifestOut); err != nil {
+		return err
+	}
+	return partialError(cmd, failed)
+}
+
+func writeManifest(m snapshot.Manifest, path string) error {
+	if path == "" {
+		return m.Write(os.Stdout)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("create manifest: %w", err)
+	}
+	if err := m.Write(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("close manifest: %w", err)
+	}
+	return nil
+}
+
+func runSnapshotCheck(cmd *cobra.Command, args []string) error {
+	manifestPath, root := args[0], args[1]
+
+	formatOpts, err := formatOptions()
+	if err != nil {
+		return err
+	}
+	formatOpts.TimeStyle = formatter.TimeStyleFullISO
+
+	enc, err := formatter.NewEncoder(output, formatOpts)
+	if err != nil {
+		return err
+	}
+
+	m, err := snapshot.Load(manifestPath)
+	if err != nil {
+		return err
+	}
+
+	opts, err := diffOptions()
+	if err != nil {
+		return err
+	}
+	switch {
+	case m.Checksums:
+		opts.Fields |= diff.FieldContent
+	case opts.Fields&diff.FieldContent != 0:
+		return errors.New("the manifest has no checksums; save it with --checksum to compare contents")
+	}
+
+	baseline, sums, err := snapshot.Tree(m)
+	if err != nil {
+		return err
+	}
+	baseline.Path = fmt.Sprintf("%s (%s, %s)", manifestPath, m.Root, m.Created.Format(time.RFC3339))
+	opts.Checksums = sums
+
+	opts.Finder.Sort = finder.SortName
+	current, err := finder.Tree(cmd.Context(), root, opts.Finder)
+	if err != nil {
+		return fmt.Errorf("check %s: %w", root, err)
+	}
+
+	node, err := diff.CompareTrees(cmd.Context(), baseline, current, opts)
+	if err != nil {
+		return fmt.Errorf("check %s: %w", root, err)
+	}
+	if !showAll {
+		node = node.Prune()
+	}
+	if err := enc.EncodeDiff(os.Stdout, node); err != nil {
+		return err
+	}
+	return diffResult(cmd, node)
+}
@@ -10,20 +10,68 @@ import (
 )
 
 var treeCommand = &cobra.Command{
-	Use:   "tree [path]",
+	Use:   "tree [path...]",
 	Short: "display directory tree",
 	Long:  "display directory structure in tree format",
-	Args:  cobra.MaximumNArgs(1),
+	Args:  cobra.ArbitraryArgs,
 	RunE:  runTree,
 }
 
+func init() {
+	addOutputFlag(treeCommand)
+	addSortFlags(treeCommand)
+	addFilterFlags(treeCommand)
+	addTreeFlags(treeCommand)
+	addWalkFlags(treeCommand)
+	addUsageFlags(treeCommand)
+	addStdinFlags(treeCommand)
+	addTypeFlags(treeCommand)
+	treeCommand.Flags().StringVar(&export, "export", "",
+		"render the tree as md, md-box, html, mermaid or dot")
+}
+
 func runTree(cmd *cobra.Command, args []string) error {
-	path := pathArg(args)
+	formatOpts, err := formatOptions()
+	if err != nil {
+		return err
+	}
+
+	enc, err := formatter.NewEncoder(output, formatOpts)
+	if err != nil {
+		return err
+	}
 
-	node, err := finder.Tree(path)
+	opts, err := finderOptions()
 	if err != nil {
-		return fmt.Errorf("tree %s: %w", path, err)
+		return err
+	}
+	if export != "" && len(args) > 1 {
+		return fmt.Errorf("--export takes a single path")
+	}
+
+	if fromStdin {
+		paths, err := readPathList(os.Stdin, nullSep)
+		if err != nil {
+			return err
+		}
+		node, err := finder.TreeFromPaths(cmd.Context(), paths, opts)
+		if err != nil {
+			return err
+		}
+		if err := enc.EncodeTree(os.Stdout, node); err != nil {
+			return err
+		}
+		return partialError(cmd, node.ErrorCount())
 	}
 
-	return formatter.PrintTree(os.Stdout, node)
+	return eachPath(cmd, pathArgs(args), enc, func(path string, enc formatter.Encoder) (int, error) {
+		node, err := finder.Tree(cmd.Context(), path, opts)
+		if err != nil {
+			return 0, fmt.Errorf("tree %s: %w", path, err)
+		}
+		if err := enc.EncodeTree(os.Stdout, node); err != nil {
+			return 0, err
+		}
+		return node.ErrorCount(), nil
+	})
 }
@@ -0,0 +1,225 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/ymatsukawa/lsmod/finder"
+	"github.com/ymatsukawa/lsmod/formatter"
+	"github.com/ymatsukawa/lsmod/watch"
+)
+
+const watchDebounce = 100 * time.Millisecond
+
+var (
+	watchTree bool
+	watchLog  bool
+	highlight time.Duration
+)
+
+var watchCommand = &cobra.Command{
+	Use:   "watch [path]",
+	Short: "redraw the listing when files change",
+	Long:  "keep the l or tree view on screen and redraw it when the filesystem changes",
+	Args:  cobra.MaximumNArgs(1),
+	RunE:  runWatch,
+}
+
+func init() {
+	addColorFlag(watchCommand)
+	addSortFlags(watchCommand)
+	addFilterFlags(watchCommand)
+	addSizeFlags(watchCommand)
+	watchCommand.Flags().BoolVar(&watchTree, "tree", false, "show the tree view instead of the listing")
+	watchCommand.Flags().IntVarP(&maxDepth, "depth", "L", 0, "descend at most N levels in the tree view (0 for no limit)")
+	watchCommand.Flags().BoolVar(&watchLog, "log", false, "print a timestamped line per event instead of redrawing")
+	watchCommand.Flags().DurationVar(&highlight, "highlight", 3*time.Second, "how long changed entries stay highlighted")
+}
+
+type watchState struct {
+	changes map[string]formatter.Change
+	deleted map[string]bool
+	expires map[string]time.Time
+}
+
+func (s *watchState) record(ev watch.Event, until time.Time) {
+	switch ev.Op {
+	case watch.Create:
+		delete(s.deleted, ev.Path)
+		s.changes[ev.Path] = formatter.ChangeCreated
+	case watch.Delete:
+		if s.changes[ev.Path] == formatter.ChangeCreated {
+			delete(s.changes, ev.Path)
+			delete(s.expires, ev.Path)
+			return
+		}
+		delete(s.changes, ev.Path)
+		s.deleted[ev.Path] = true
+	default:
+		if s.changes[ev.Path] != formatter.ChangeCreated {
+			s.changes[ev.Path] = formatter.ChangeModified
+		}
+	}
+	s.expires[ev.Path] = until
+}
+
+func (s *watchState) expire(now time.Time) bool {
+	expired := false
+	for path, until := range s.expires {
+		if now.Before(until) {
+			continue
+		}
+		delete(s.expires, path)
+		delete(s.changes, path)
+		delete(s.deleted, path)
+		expired = true
+	}
+	return expired
+}
+
+func (s *watchState) deletedPaths(root string) []string {
+	paths := make([]string, 0, len(s.deleted))
+	for path := range s.deleted {
+		if rel, err := filepath.Rel(root, path); err == nil {
+			path = rel
+		}
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func runWatch(cmd *cobra.Command, args []string) error {
+	if highlight <= 0 {
+		return fmt.Errorf("--highlight must be positive")
+	}
+
+	root, err := filepath.Abs(pathArgs(args)[0])
+	if err != nil {
+		return fmt.Errorf("resolve path: %w", err)
+	}
+
+	w, err := watch.New(root, watchTree || watchLog)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	if watchLog {
+		return logEvents(cmd, root, w)
+	}
+	return redrawOnChange(cmd, root, w)
+}
+
+func logEvents(cmd *cobra.Command, root string, w *watch.Watcher) error {
+	for {
+		select {
+		case <-cmd.Context().Done():
+			return nil
+		case err := <-w.Errors:
+			fmt.Fprintf(os.Stderr, "%s: %v\n", cmd.Root().Name(), err)
+		case ev, ok := <-w.Events:
+			if !ok {
+				return nil
+			}
+			rel, err := filepath.Rel(root, ev.Path)
+			if err != nil {
+				rel = ev.Path
+			}
+			if ev.IsDir {
+				rel += "/"
+			}
+			fmt.Fprintf(os.Stdout, "%s %-6s %s\n", ev.Time.Format("15:04:05.000"), ev.Op, rel)
+		}
+	}
+}
+
+func redrawOnChange(cmd *cobra.Command, root string, w *watch.Watcher) error {
+	formatOpts, err := formatOptions()
+	if err != nil {
+		return err
+	}
+	opts, err := finderOptions()
+	if err != nil {
+		return err
+	}
+
+	state := &watchState{
+		changes: make(map[string]formatter.Change),
+		deleted: make(map[string]bool),
+		expires: make(map[string]time.Time),
+	}
+
+	ticker := time.NewTicker(highlight / 4)
+	defer ticker.Stop()
+	debounce := time.NewTimer(0)
+	defer debounce.Stop()
+
+	var lastErr error
+	for {
+		select {
+		case <-cmd.Context().Done():
+			return nil
+		case err := <-w.Errors:
+			lastErr = err
+			debounce.Reset(watchDebounce)
+		case ev, ok := <-w.Events:
+			if !ok {
+				return nil
+			}
+			state.record(ev, ev.Time.Add(highlight))
+			debounce.Reset(watchDebounce)
+		case now := <-ticker.C:
+			if state.expire(now) {
+				debounce.Reset(watchDebounce)
+			}
+		case <-debounce.C:
+			formatOpts.Changes = state.changes
+			if err := drawWatch(cmd, root, opts, formatOpts, state, lastErr); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func drawWatch(cmd *cobra.Command, root string, opts finder.Options, formatOpts formatter.Options, state *watchState, lastErr error) error {
+	var buf bytes.Buffer
+	buf.WriteString("\033[H\033[2J")
+	fmt.Fprintf(&buf, "watching %s  %s\n\n", root, time.Now().Format("15:04:05"))
+
+	if watchTree {
+		node, err := finder.Tree(cmd.Context(), root, opts)
+		if err != nil {
+			fmt.Fprintln(&buf, err)
+		} else if err := formatter.PrintTree(&buf, node, formatOpts); err != nil {
+			return err
+		}
+	} else {
+		entries, err := finder.Find(cmd.Context(), root, opts)
+		if err != nil {
+			fmt.Fprintln(&buf, err)
+		} else if err := formatter.Print(&buf, entries, formatOpts); err != nil {
+			return err
+		}
+	}
+
+	if deleted := state.deletedPaths(root); len(deleted) > 0 {
+		buf.WriteString("\n")
+		if err := formatter.PrintDeleted(&buf, deleted, formatOpts); err != nil {
+			return err
+		}
+	}
+	if lastErr != nil {
+		fmt.Fprintf(&buf, "\n%v\n", lastErr)
+	}
+
+	if _, err := os.Stdout.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("write screen: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,311 @@
+package diff
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/ymatsukawa/lsmod/finder"
+)
+
+type Status int
+
+const (
+	Same Status = iota
+	Added
+	Removed
+	Changed
+)
+
+type Field int
+
+const (
+	FieldType Field = 1 << iota
+	FieldSize
+	FieldMode
+	FieldOwner
+	FieldMtime
+	FieldContent
+)
+
+var fieldNames = []struct {
+	field Field
+	name  string
+}{
+	{FieldType, "type"},
+	{FieldSize, "size"},
+	{FieldMode, "mode"},
+	{FieldOwner, "owner"},
+	{FieldMtime, "mtime"},
+	{FieldContent, "content"},
+}
+
+const DefaultFields = FieldType | FieldSize | FieldMode | FieldOwner | FieldMtime
+
+func ParseFields(list []string) (Field, error) {
+	var fields Field
+	for _, name := range list {
+		found := false
+		for _, f := range fieldNames {
+			if f.name == strings.TrimSpace(name) {
+				fields |= f.field
+				found = true
+			}
+		}
+		if !found {
+			return 0, fmt.Errorf("unknown field %q (want type, size, mode, owner, mtime or content)", name)
+		}
+	}
+	return fields, nil
+}
+
+func (f Field) Names() []string {
+	var names []string
+	for _, n := range fieldNames {
+		if f&n.field != 0 {
+			names = append(names, n.name)
+		}
+	}
+	return names
+}
+
+type Options struct {
+	Finder    finder.Options
+	Fields    Field
+	Checksums map[string]string
+}
+
+type Node struct {
+	Name     string
+	Path     string
+	Status   Status
+	Changes  Field
+	Old      *finder.Entry
+	New      *finder.Entry
+	Err      error
+	Children []Node
+}
+
+func (n Node) Dirty() bool {
+	if n.Status != Same {
+		return true
+	}
+	for _, child := range n.Children {
+		if child.Dirty() {
+			return true
+		}
+	}
+	return false
+}
+
+func (n Node) Prune() Node {
+	children := n.Children
+	n.Children = nil
+	for _, child := range children {
+		if child.Dirty() || child.Err != nil {
+			n.Children = append(n.Children, child.Prune())
+		}
+	}
+	return n
+}
+
+func (n Node) Count() (added, removed, changed int) {
+	switch n.Status {
+	case Added:
+		added++
+	case Removed:
+		removed++
+	case Changed:
+		changed++
+	}
+	for _, child := range n.Children {
+		a, r, c := child.Count()
+		added, removed, changed = added+a, removed+r, changed+c
+	}
+	return added, removed, changed
+}
+
+func (n Node) ErrorCount() int {
+	count := 0
+	if n.Err != nil {
+		count++
+	}
+	for _, e := range []*finder.Entry{n.Old, n.New} {
+		if e != nil && e.Err != nil {
+			count++
+		}
+	}
+	for _, child := range n.Children {
+		count += child.ErrorCount()
+	}
+	return count
+}
+
+func Compare(ctx context.Context, a, b string, opts Options) (Node, error) {
+	opts.Finder.Sort = finder.SortName
+	opts.Finder.Sizes = false
+	opts.Finder.MaxEntries = 0
+
+	left, err := finder.Tree(ctx, a, opts.Finder)
+	if err != nil {
+		return Node{}, err
+	}
+	right, err := finder.Tree(ctx, b, opts.Finder)
+	if err != nil {
+		return Node{}, err
+	}
+	return CompareTrees(ctx, left, right, opts)
+}
+
+func CompareTrees(ctx context.Context, left, right finder.TreeNode, opts Options) (Node, error) {
+	c := comparer{ctx: ctx, fields: opts.Fields, sums: opts.Checksums}
+	if opts.Fields&FieldContent != 0 {
+		// Checksums from a snapshot stand in for the old side's files.
+		if opts.Checksums == nil {
+			files, err := finder.OpenFiles(left.Path, opts.Finder)
+			if err != nil {
+				return Node{}, err
+			}
+			defer files.Close()
+			c.old = files
+		}
+		files, err := finder.OpenFiles(right.Path, opts.Finder)
+		if err != nil {
+			return Node{}, err
+		}
+		defer files.Close()
+		c.new = files
+	}
+
+	root := c.merge(".", ".", &left, &right)
+	if err := ctx.Err(); err != nil {
+		return Node{}, err
+	}
+	return root, nil
+}
+
+type comparer struct {
+	ctx      context.Context
+	fields   Field
+	sums     map[string]string
+	old, new *finder.Files
+}
+
+func (c *comparer) merge(name, rel string, left, right *finder.TreeNode) Node {
+	node := Node{Name: name, Path: rel}
+	if left != nil {
+		node.Old = &left.Entry
+	}
+	if right != nil {
+		node.New = &right.Entry
+	}
+
+	switch {
+	case left == nil:
+		node.Status = Added
+	case right == nil:
+		node.Status = Removed
+	default:
+		node.Changes, node.Err = c.compare(left.Entry, right.Entry)
+		if node.Changes != 0 {
+			node.Status = Changed
+		}
+	}
+
+	olds, news := index(left), index(right)
+	for _, childName := range union(olds, news) {
+		node.Children = append(node.Children,
+			c.merge(childName, path.Join(rel, childName), olds[childName], news[childName]))
+	}
+	return node
+}
+
+func (c *comparer) compare(a, b finder.Entry) (Field, error) {
+	if a.Err != nil || b.Err != nil {
+		return 0, nil
+	}
+
+	var changes Field
+	dir := a.IsDir && b.IsDir
+	if c.fields&FieldType != 0 && (a.FileMode.Type() != b.FileMode.Type() || a.LinkTarget != b.LinkTarget) {
+		changes |= FieldType
+	}
+	if c.fields&FieldSize != 0 && !dir && a.Size != b.Size {
+		changes |= FieldSize
+	}
+	if c.fields&FieldMode != 0 && a.FileMode&^os.ModeType != b.FileMode&^os.ModeType {
+		changes |= FieldMode
+	}
+	if c.fields&FieldOwner != 0 && (a.UID != b.UID || a.GID != b.GID) {
+		changes |= FieldOwner
+	}
+	if c.fields&FieldMtime != 0 && !dir && !a.ModTime.Equal(b.ModTime) {
+		changes |= FieldMtime
+	}
+	if c.fields&FieldContent != 0 && a.FileMode.IsRegular() && b.FileMode.IsRegular() {
+		differs, err := c.contentDiffers(a, b)
+		if err != nil {
+			return changes, err
+		}
+		if differs {
+			changes |= FieldContent
+		}
+	}
+	return changes, nil
+}
+
+func (c *comparer) contentDiffers(a, b finder.Entry) (bool, error) {
+	if a.Size != b.Size {
+		return true, nil
+	}
+
+	sumA, err := c.checksum(c.old, a)
+	if err != nil {
+		return false, err
+	}
+	sumB, err := c.checksum(c.new, b)
+	if err != nil {
+		return false, err
+	}
+	return sumA != sumB, nil
+}
+
+func (c *comparer) checksum(files *finder.Files, e finder.Entry) (string, error) {
+	if sum, ok := c.sums[e.Path]; ok {
+		return sum, nil
+	}
+	if files == nil {
+		return "", fmt.Errorf("%s: no checksum recorded", e.Path)
+	}
+	return files.Checksum(c.ctx, e.Path)
+}
+
+func index(n *finder.TreeNode) map[string]*finder.TreeNode {
+	if n == nil {
+		return nil
+	}
+	children := make(map[string]*finder.TreeNode, len(n.Children))
+	for i := range n.Children {
+		if !n.Children[i].Collapsed() {
+			children[n.Children[i].Name] = &n.Children[i]
+		}
+	}
+	return children
+}
+
+func union(a, b map[string]*finder.TreeNode) []string {
+	names := make([]string, 0, len(a)+len(b))
+	for name := range a {
+		names = append(names, name)
+	}
+	for name := range b {
+		if _, ok := a[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
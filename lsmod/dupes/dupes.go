@@ -0,0 +1,289 @@
+package dupes
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"sync"
+
+	"github.com/ymatsukawa/lsmod/finder"
+)
+
+const sampleSize = 4096
+
+type Options struct {
+	Finder    finder.Options
+	Hardlinks bool
+}
+
+type Group struct {
+	Size  int64
+	Hash  string
+	Files []finder.Entry
+}
+
+func (g Group) Wasted() int64 {
+	return g.Size * int64(len(g.Files)-1)
+}
+
+type Result struct {
+	Groups []Group
+	Failed int
+}
+
+func (r Result) Wasted() int64 {
+	var total int64
+	for _, g := range r.Groups {
+		total += g.Wasted()
+	}
+	return total
+}
+
+func Find(ctx context.Context, root string, opts Options) (Result, error) {
+	fopts := opts.Finder
+	fopts.Sizes = false
+	fopts.MaxEntries = 0
+	fopts.DirsOnly = false
+
+	tree, err := finder.Tree(ctx, root, fopts)
+	if err != nil {
+		return Result{}, err
+	}
+
+	files, err := finder.OpenFiles(root, fopts)
+	if err != nil {
+		return Result{}, err
+	}
+	defer files.Close()
+
+	var result Result
+	result.Failed = tree.ErrorCount()
+
+	buckets := bySize(collect(tree, root, nil), opts.Hardlinks)
+
+	workers := opts.Finder.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	h := hasher{ctx: ctx, workers: workers}
+
+	sampled := h.regroup(buckets, func(ctx context.Context, e finder.Entry) (string, error) {
+		return sampleHash(ctx, files, e)
+	})
+	full := h.regroup(sampled, func(ctx context.Context, e finder.Entry) (string, error) {
+		if e.Size <= 2*sampleSize {
+			return "", nil
+		}
+		return files.Checksum(ctx, e.Path)
+	})
+	if err := ctx.Err(); err != nil {
+		return Result{}, err
+	}
+
+	for _, g := range full {
+		sortFiles(g.Files)
+		result.Groups = append(result.Groups, g)
+	}
+	sort.SliceStable(result.Groups, func(i, j int) bool {
+		a, b := result.Groups[i], result.Groups[j]
+		if a.Wasted() != b.Wasted() {
+			return a.Wasted() > b.Wasted()
+		}
+		return a.Files[0].Name < b.Files[0].Name
+	})
+	result.Failed += h.failed
+	return result, nil
+}
+
+func collect(node finder.TreeNode, rel string, files []finder.Entry) []finder.Entry {
+	for _, child := range node.Children {
+		if child.Collapsed() || child.Err != nil {
+			continue
+		}
+		path := filepath.Join(rel, child.Name)
+		if child.FileMode.IsRegular() && child.Size > 0 {
+			e := child.Entry
+			e.Name = path
+			files = append(files, e)
+		}
+		files = collect(child, path, files)
+	}
+	return files
+}
+
+func bySize(files []finder.Entry, hardlinks bool) []Group {
+	sizes := make(map[int64][]finder.Entry)
+	for _, f := range files {
+		sizes[f.Size] = append(sizes[f.Size], f)
+	}
+
+	var groups []Group
+	for size, entries := range sizes {
+		if hardlinks {
+			entries = uniqueInodes(entries)
+		}
+		if len(entries) > 1 {
+			groups = append(groups, Group{Size: size, Files: entries})
+		}
+	}
+	return groups
+}
+
+func uniqueInodes(entries []finder.Entry) []finder.Entry {
+	type inode struct{ dev, ino uint64 }
+	seen := make(map[inode]bool, len(entries))
+	unique := entries[:0:0]
+	for _, e := range entries {
+		id := inode{e.Dev, e.Inode}
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		unique = append(unique, e)
+	}
+	return unique
+}
+
+type hashFunc func(ctx context.Context, e finder.Entry) (string, error)
+
+type hasher struct {
+	ctx     context.Context
+	workers int
+
+	mu     sync.Mutex
+	failed int
+}
+
+type hashJob struct {
+	group int
+	file  int
+}
+
+func (h *hasher) regroup(groups []Group, hash hashFunc) []Group {
+	sums := make([][]string, len(groups))
+	failed := make([][]bool, len(groups))
+	jobs := make(chan hashJob)
+	for i, g := range groups {
+		sums[i] = make([]string, len(g.Files))
+		failed[i] = make([]bool, len(g.Files))
+	}
+
+	var wg sync.WaitGroup
+	for range h.workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				sum, err := hash(h.ctx, groups[job.group].Files[job.file])
+				if err != nil {
+					h.mu.Lock()
+					h.failed++
+					h.mu.Unlock()
+					failed[job.group][job.file] = true
+				}
+				sums[job.group][job.file] = sum
+			}
+		}()
+	}
+
+feed:
+	for i, g := range groups {
+		for j := range g.Files {
+			select {
+			case jobs <- hashJob{group: i, file: j}:
+			case <-h.ctx.Done():
+				break feed
+			}
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	var out []Group
+	for i, g := range groups {
+		byHash := make(map[string][]finder.Entry)
+		var order []string
+		for j, f := range g.Files {
+			if failed[i][j] {
+				continue
+			}
+			sum := sums[i][j]
+			if _, ok := byHash[sum]; !ok {
+				order = append(order, sum)
+			}
+			byHash[sum] = append(byHash[sum], f)
+		}
+		for _, sum := range order {
+			if files := byHash[sum]; len(files) > 1 {
+				hash := g.Hash
+				if sum != "" {
+					hash = sum
+				}
+				out = append(out, Group{Size: g.Size, Hash: hash, Files: files})
+			}
+		}
+	}
+	return out
+}
+
+func sampleHash(ctx context.Context, files *finder.Files, e finder.Entry) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	if files.Streamed(e.Path) {
+		return files.Checksum(ctx, e.Path)
+	}
+
+	f, err := files.Open(e.Path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if e.Size <= 2*sampleSize {
+		if _, err := io.Copy(h, f); err != nil {
+			return "", fmt.Errorf("read %s: %w", e.Path, err)
+		}
+		return hex.EncodeToString(h.Sum(nil)), nil
+	}
+
+	buf := make([]byte, sampleSize)
+	if _, err := io.ReadFull(f, buf); err != nil {
+		return "", fmt.Errorf("read %s: %w", e.Path, err)
+	}
+	h.Write(buf)
+	if err := readTail(f, buf, e.Size); err != nil {
+		return "", fmt.Errorf("read %s: %w", e.Path, err)
+	}
+	h.Write(buf)
+	return "sample:" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// readTail fills buf with the last len(buf) bytes of a file whose first
+// len(buf) bytes were just read. Archive members that cannot seek are
+// read through to the tail.
+func readTail(f io.Reader, buf []byte, size int64) error {
+	offset := size - int64(len(buf))
+	if ra, ok := f.(io.ReaderAt); ok {
+		if _, err := ra.ReadAt(buf, offset); err != nil && err != io.EOF {
+			return err
+		}
+		return nil
+	}
+
+	if _, err := io.CopyN(io.Discard, f, offset-int64(len(buf))); err != nil {
+		return err
+	}
+	_, err := io.ReadFull(f, buf)
+	return err
+}
+
+func sortFiles(files []finder.Entry) {
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+}
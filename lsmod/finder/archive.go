@@ -0,0 +1,800 @@
+package finder
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+type archiveFormat int
+
+const (
+	formatNone archiveFormat = iota
+	formatZip
+	formatTar
+	formatTarGz
+)
+
+func archiveFormatOf(name string) archiveFormat {
+	name = strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return formatTarGz
+	case strings.HasSuffix(name, ".tar"):
+		return formatTar
+	case strings.HasSuffix(name, ".zip"), strings.HasSuffix(name, ".jar"),
+		strings.HasSuffix(name, ".war"), strings.HasSuffix(name, ".ear"):
+		return formatZip
+	}
+	return formatNone
+}
+
+const maxLinks = 40
+
+// maxMountMemory is the largest nested archive read into memory; bigger
+// ones are copied to an unlinked temporary file.
+const maxMountMemory = 32 << 20
+
+var archiveDevs atomic.Uint64
+
+// memberStat is what archive members report from Sys(), standing in for
+// syscall.Stat_t. Inode numbers are synthetic and unique per archive.
+type memberStat struct {
+	Dev   uint64
+	Ino   uint64
+	Nlink uint64
+	Rdev  uint64
+	UID   uint32
+	GID   uint32
+	Owner string
+	Group string
+	Atime time.Time
+	Ctime time.Time
+}
+
+type memberInfo struct {
+	name  string
+	size  int64
+	mode  fs.FileMode
+	mtime time.Time
+	stat  *memberStat
+}
+
+func (i memberInfo) Name() string       { return i.name }
+func (i memberInfo) Size() int64        { return i.size }
+func (i memberInfo) Mode() fs.FileMode  { return i.mode }
+func (i memberInfo) ModTime() time.Time { return i.mtime }
+func (i memberInfo) IsDir() bool        { return i.mode.IsDir() }
+func (i memberInfo) Sys() any           { return i.stat }
+
+type member struct {
+	name     string
+	info     memberInfo
+	link     string
+	hardlink *member
+	archive  bool
+	children map[string]*member
+	sorted   []*member
+
+	// index counts tar headers; offset is where the data of a plain tar
+	// member starts, or -1 when it has to be found by scanning. head keeps
+	// the first bytes of compressed tar members for type detection.
+	index  int
+	offset int64
+	head   []byte
+	zip    *zip.File
+}
+
+type archiveOptions struct {
+	nested bool
+	heads  bool
+}
+
+type archiveFS struct {
+	format archiveFormat
+	data   io.ReaderAt
+	size   int64
+	opts   archiveOptions
+	dev    uint64
+	inodes uint64
+	root   *member
+	byName map[string]*member
+
+	mu     sync.Mutex
+	mounts map[*member]*archiveFS
+	spills []*os.File
+
+	sumOnce sync.Once
+	sums    map[int]string
+	sumErr  error
+}
+
+func newArchiveFS(data io.ReaderAt, size int64, format archiveFormat, root memberInfo, opts archiveOptions) (*archiveFS, error) {
+	a := &archiveFS{
+		format: format,
+		data:   data,
+		size:   size,
+		opts:   opts,
+		dev:    1<<63 | archiveDevs.Add(1),
+		byName: make(map[string]*member),
+		mounts: make(map[*member]*archiveFS),
+	}
+
+	root.stat.Dev, root.stat.Ino = a.dev, a.inode()
+	a.root = &member{name: ".", info: root}
+
+	var err error
+	if format == formatZip {
+		err = a.indexZip()
+	} else {
+		err = a.indexTar()
+	}
+	if err != nil {
+		return nil, err
+	}
+	a.link()
+	return a, nil
+}
+
+func (a *archiveFS) inode() uint64 {
+	a.inodes++
+	return a.inodes
+}
+
+func memberName(name string) (string, bool) {
+	name = path.Clean("/" + name)[1:]
+	if name == "" {
+		return "", false
+	}
+	return name, true
+}
+
+func (a *archiveFS) add(m *member) {
+	m.info.name = path.Base(m.name)
+	if m.info.stat == nil {
+		m.info.stat = &memberStat{}
+	}
+	m.info.stat.Dev = a.dev
+	if m.info.stat.Atime.IsZero() {
+		m.info.stat.Atime = m.info.mtime
+	}
+	if m.info.stat.Ctime.IsZero() {
+		m.info.stat.Ctime = m.info.mtime
+	}
+	if m.info.stat.Ino == 0 {
+		m.info.stat.Ino = a.inode()
+		m.info.stat.Nlink = 1
+	}
+	if a.opts.nested && m.info.mode.IsRegular() && archiveFormatOf(m.name) != formatNone {
+		m.archive = true
+		m.info.mode |= fs.ModeDir
+	}
+	a.byName[m.name] = m
+}
+
+func (a *archiveFS) indexTar() error {
+	r, closeFn, err := a.tarStream()
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+
+	seeker, _ := r.(io.Seeker)
+	tr := tar.NewReader(r)
+	for index := 0; ; index++ {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		name, ok := memberName(hdr.Name)
+		if !ok {
+			continue
+		}
+
+		m := &member{name: name, index: index, offset: -1}
+		if seeker != nil && !sparse(hdr) {
+			if m.offset, err = seeker.Seek(0, io.SeekCurrent); err != nil {
+				return err
+			}
+		}
+		if seeker == nil && a.opts.heads && regular(hdr) {
+			m.head = make([]byte, min(hdr.Size, sniffLen))
+			if _, err := io.ReadFull(tr, m.head); err != nil {
+				return err
+			}
+		}
+		if hdr.Typeflag == tar.TypeLink {
+			target, ok := memberName(hdr.Linkname)
+			if t := a.byName[target]; ok && t != nil && !t.info.IsDir() {
+				t.info.stat.Nlink++
+				m.hardlink = t
+				m.info = t.info
+				a.add(m)
+				continue
+			}
+		}
+
+		m.info = memberInfo{
+			size:  hdr.Size,
+			mode:  hdr.FileInfo().Mode(),
+			mtime: hdr.ModTime,
+			stat: &memberStat{
+				Rdev:  mkdev(uint64(hdr.Devmajor), uint64(hdr.Devminor)),
+				UID:   uint32(hdr.Uid),
+				GID:   uint32(hdr.Gid),
+				Owner: ownerName(hdr.Uname, hdr.Uid),
+				Group: ownerName(hdr.Gname, hdr.Gid),
+				Atime: hdr.AccessTime,
+				Ctime: hdr.ChangeTime,
+			},
+		}
+		if hdr.Typeflag == tar.TypeSymlink {
+			// Like lstat, report the length of the target as the size.
+			m.link = hdr.Linkname
+			m.info.size = int64(len(hdr.Linkname))
+		}
+		a.add(m)
+	}
+}
+
+// regular reports whether a tar member carries file data of its own.
+func regular(hdr *tar.Header) bool {
+	return hdr.Typeflag != tar.TypeLink && hdr.FileInfo().Mode().IsRegular()
+}
+
+// sparse reports whether a tar member stores holes, whose data is not one
+// contiguous run of the archive.
+func sparse(hdr *tar.Header) bool {
+	return hdr.Typeflag == tar.TypeGNUSparse ||
+		hdr.PAXRecords["GNU.sparse.major"] != "" || hdr.PAXRecords["GNU.sparse.map"] != ""
+}
+
+func (a *archiveFS) indexZip() error {
+	zr, err := zip.NewReader(a.data, a.size)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range zr.File {
+		name, ok := memberName(f.Name)
+		if !ok {
+			continue
+		}
+
+		stat := &memberStat{Owner: "-", Group: "-"}
+		if uid, gid, ok := zipOwner(f.Extra); ok {
+			stat.UID, stat.GID = uid, gid
+			stat.Owner, stat.Group = ownerName("", int(uid)), ownerName("", int(gid))
+		}
+		m := &member{
+			name: name,
+			zip:  f,
+			info: memberInfo{
+				size:  int64(f.UncompressedSize64),
+				mode:  f.Mode(),
+				mtime: f.Modified,
+				stat:  stat,
+			},
+		}
+		if m.info.mode&fs.ModeSymlink != 0 {
+			m.link, _ = readZipLink(f)
+		}
+		a.add(m)
+	}
+	return nil
+}
+
+func readZipLink(f *zip.File) (string, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+
+	target, err := io.ReadAll(io.LimitReader(rc, 4096))
+	return string(target), err
+}
+
+// zipOwner reads the Info-ZIP "ux" extra field, which carries the uid and
+// gid of the archived file when it was created on a Unix system.
+func zipOwner(extra []byte) (uid, gid uint32, ok bool) {
+	for len(extra) >= 4 {
+		tag := binary.LittleEndian.Uint16(extra)
+		size := int(binary.LittleEndian.Uint16(extra[2:]))
+		if size > len(extra)-4 {
+			return 0, 0, false
+		}
+		field := extra[4 : 4+size]
+		extra = extra[4+size:]
+		if tag != 0x7875 || len(field) < 2 || field[0] != 1 {
+			continue
+		}
+
+		uid, field, ok = zipID(field[1:])
+		if !ok {
+			return 0, 0, false
+		}
+		gid, _, ok = zipID(field)
+		return uid, gid, ok
+	}
+	return 0, 0, false
+}
+
+func zipID(field []byte) (uint32, []byte, bool) {
+	if len(field) < 1 || len(field) < 1+int(field[0]) {
+		return 0, nil, false
+	}
+	n := int(field[0])
+	var id uint64
+	for i := n - 1; i >= 0; i-- {
+		id = id<<8 | uint64(field[1+i])
+	}
+	return uint32(id), field[1+n:], true
+}
+
+func ownerName(name string, id int) string {
+	if name != "" {
+		return name
+	}
+	return strconv.Itoa(id)
+}
+
+func mkdev(major, minor uint64) uint64 {
+	return (major&0xfffff000)<<32 | (major&0xfff)<<8 | (minor&0xffffff00)<<12 | minor&0xff
+}
+
+// link fills in directories that only appear as path prefixes and wires
+// every member to its parent.
+func (a *archiveFS) link() {
+	names := make([]string, 0, len(a.byName))
+	for name := range a.byName {
+		names = append(names, name)
+	}
+	for _, name := range names {
+		for dir := path.Dir(name); dir != "."; dir = path.Dir(dir) {
+			if m, ok := a.byName[dir]; ok && m.info.IsDir() {
+				break
+			}
+			a.add(&member{name: dir, info: memberInfo{
+				mode:  fs.ModeDir | 0o755,
+				mtime: a.root.info.mtime,
+				stat:  &memberStat{Owner: "-", Group: "-"},
+			}})
+		}
+	}
+
+	a.byName["."] = a.root
+	for name, m := range a.byName {
+		if name == "." {
+			continue
+		}
+		parent := a.byName[path.Dir(name)]
+		if parent.children == nil {
+			parent.children = make(map[string]*member)
+		}
+		parent.children[m.info.name] = m
+	}
+	for _, m := range a.byName {
+		for _, child := range m.children {
+			m.sorted = append(m.sorted, child)
+		}
+		slices.SortFunc(m.sorted, func(x, y *member) int {
+			return strings.Compare(x.info.name, y.info.name)
+		})
+	}
+}
+
+func (a *archiveFS) tarStream() (io.Reader, func(), error) {
+	r := io.NewSectionReader(a.data, 0, a.size)
+	if a.format != formatTarGz {
+		return r, func() {}, nil
+	}
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	return gz, func() { gz.Close() }, nil
+}
+
+func (a *archiveFS) openMember(m *member) (io.ReadCloser, error) {
+	if m.hardlink != nil {
+		m = m.hardlink
+	}
+	switch {
+	case m.zip != nil:
+		return m.zip.Open()
+	case m.offset >= 0:
+		return sectionMember{io.NewSectionReader(a.data, m.offset, m.info.size)}, nil
+	case m.head != nil:
+		return &headMember{m: m, open: a.scanMember}, nil
+	}
+	return a.scanMember(m)
+}
+
+// scanMember reads a tar from the start up to m, the only way to reach a
+// member of a compressed stream.
+func (a *archiveFS) scanMember(m *member) (io.ReadCloser, error) {
+	r, closeFn, err := a.tarStream()
+	if err != nil {
+		return nil, err
+	}
+	tr := tar.NewReader(r)
+	for index := 0; ; index++ {
+		if _, err := tr.Next(); err != nil {
+			closeFn()
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		if index == m.index {
+			return tarMember{Reader: tr, close: closeFn}, nil
+		}
+	}
+}
+
+type tarMember struct {
+	io.Reader
+	close func()
+}
+
+func (t tarMember) Close() error {
+	t.close()
+	return nil
+}
+
+type sectionMember struct {
+	*io.SectionReader
+}
+
+func (sectionMember) Close() error {
+	return nil
+}
+
+// headMember serves the bytes kept from indexing and only decompresses
+// the archive up to the member once a read goes past them.
+type headMember struct {
+	m    *member
+	open func(*member) (io.ReadCloser, error)
+	rest io.ReadCloser
+	off  int64
+}
+
+func (h *headMember) Read(p []byte) (int, error) {
+	if h.off < int64(len(h.m.head)) {
+		n := copy(p, h.m.head[h.off:])
+		h.off += int64(n)
+		return n, nil
+	}
+	if h.off >= h.m.info.size {
+		return 0, io.EOF
+	}
+
+	if h.rest == nil {
+		rc, err := h.open(h.m)
+		if err != nil {
+			return 0, err
+		}
+		if _, err := io.CopyN(io.Discard, rc, h.off); err != nil {
+			rc.Close()
+			return 0, err
+		}
+		h.rest = rc
+	}
+	n, err := h.rest.Read(p)
+	h.off += int64(n)
+	return n, err
+}
+
+func (h *headMember) Close() error {
+	if h.rest != nil {
+		return h.rest.Close()
+	}
+	return nil
+}
+
+// checksum hashes a member of a compressed tar. The first call hashes
+// every regular member in one pass over the stream, since reaching any
+// one of them means decompressing everything before it anyway.
+func (a *archiveFS) checksum(ctx context.Context, m *member) (string, error) {
+	if m.hardlink != nil {
+		m = m.hardlink
+	}
+	a.sumOnce.Do(func() { a.sums, a.sumErr = a.hashMembers(ctx) })
+	if a.sumErr != nil {
+		return "", a.sumErr
+	}
+	sum, ok := a.sums[m.index]
+	if !ok {
+		return "", errors.New("not a regular file")
+	}
+	return sum, nil
+}
+
+func (a *archiveFS) hashMembers(ctx context.Context) (map[int]string, error) {
+	r, closeFn, err := a.tarStream()
+	if err != nil {
+		return nil, err
+	}
+	defer closeFn()
+
+	sums := make(map[int]string)
+	tr := tar.NewReader(ctxReader{ctx: ctx, r: r})
+	for index := 0; ; index++ {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return sums, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !regular(hdr) {
+			continue
+		}
+
+		h := sha256.New()
+		if _, err := io.Copy(h, tr); err != nil {
+			return nil, err
+		}
+		sums[index] = hex.EncodeToString(h.Sum(nil))
+	}
+}
+
+// streamed reports whether members can only be reached by decompressing
+// the archive from its start.
+func (a *archiveFS) streamed() bool {
+	return a.format == formatTarGz
+}
+
+func (a *archiveFS) mount(m *member) (*archiveFS, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if sub, ok := a.mounts[m]; ok {
+		return sub, nil
+	}
+
+	data, err := a.mountData(m)
+	if err != nil {
+		return nil, err
+	}
+
+	root := m.info
+	stat := *root.stat
+	root.stat = &stat
+	sub, err := newArchiveFS(data, root.size, archiveFormatOf(m.name), root, a.opts)
+	if err != nil {
+		return nil, err
+	}
+	a.mounts[m] = sub
+	return sub, nil
+}
+
+// mountData gives random access to a nested archive: straight from the
+// parent when the member is stored contiguously, from memory when it is
+// small, and from a temporary file otherwise.
+func (a *archiveFS) mountData(m *member) (io.ReaderAt, error) {
+	target := m
+	if m.hardlink != nil {
+		target = m.hardlink
+	}
+	if target.offset >= 0 {
+		return io.NewSectionReader(a.data, target.offset, target.info.size), nil
+	}
+
+	rc, err := a.openMember(m)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	if m.info.size <= maxMountMemory {
+		data, err := io.ReadAll(rc)
+		if err != nil {
+			return nil, err
+		}
+		return bytes.NewReader(data), nil
+	}
+
+	f, err := os.CreateTemp("", "lsmod-*")
+	if err != nil {
+		return nil, err
+	}
+	os.Remove(f.Name())
+	if _, err := io.Copy(f, rc); err != nil {
+		f.Close()
+		return nil, err
+	}
+	a.spills = append(a.spills, f)
+	return f, nil
+}
+
+func (a *archiveFS) close() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, f := range a.spills {
+		f.Close()
+	}
+	for _, sub := range a.mounts {
+		sub.close()
+	}
+}
+
+func (a *archiveFS) find(op, name string, follow, mount bool) (*archiveFS, *member, error) {
+	if !fs.ValidPath(name) {
+		return nil, nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	links := 0
+	return a.walk(op, name, name, follow, mount, &links)
+}
+
+func (a *archiveFS) walk(op, orig, name string, follow, mount bool, links *int) (*archiveFS, *member, error) {
+	if name == "." {
+		return a, a.root, nil
+	}
+
+	m := a.root
+	parts := strings.Split(name, "/")
+	for i, part := range parts {
+		last := i == len(parts)-1
+		rest := strings.Join(parts[i+1:], "/")
+
+		child := m.children[part]
+		if child == nil {
+			return nil, nil, &fs.PathError{Op: op, Path: orig, Err: fs.ErrNotExist}
+		}
+
+		if child.link != "" && (!last || follow) {
+			if *links++; *links > maxLinks {
+				return nil, nil, &fs.PathError{Op: op, Path: orig, Err: syscall.ELOOP}
+			}
+			target, ok := memberName(path.Join(path.Dir(child.name), child.link))
+			if path.IsAbs(child.link) {
+				target, ok = memberName(child.link)
+			}
+			if !ok {
+				target = "."
+			}
+			if !last {
+				target = path.Join(target, rest)
+			}
+			return a.walk(op, orig, target, follow, mount, links)
+		}
+
+		if child.archive && (!last || mount) {
+			sub, err := a.mount(child)
+			if err != nil {
+				return nil, nil, &fs.PathError{Op: op, Path: orig, Err: err}
+			}
+			if last {
+				rest = "."
+			}
+			return sub.walk(op, orig, rest, follow, mount, links)
+		}
+
+		if !last && !child.info.IsDir() {
+			return nil, nil, &fs.PathError{Op: op, Path: orig, Err: syscall.ENOTDIR}
+		}
+		m = child
+	}
+	return a, m, nil
+}
+
+func (a *archiveFS) Open(name string) (fs.File, error) {
+	fsys, m, err := a.find("open", name, true, true)
+	if err != nil {
+		return nil, err
+	}
+	if m.info.IsDir() {
+		return &archiveDir{m: m}, nil
+	}
+
+	rc, err := fsys.openMember(m)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	return &archiveFile{ReadCloser: rc, info: m.info}, nil
+}
+
+func (a *archiveFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	_, m, err := a.find("readdir", name, true, true)
+	if err != nil {
+		return nil, err
+	}
+	if !m.info.IsDir() {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: syscall.ENOTDIR}
+	}
+	return dirEntries(m.sorted), nil
+}
+
+func (a *archiveFS) Stat(name string) (fs.FileInfo, error) {
+	_, m, err := a.find("stat", name, true, false)
+	if err != nil {
+		return nil, err
+	}
+	return m.info, nil
+}
+
+func (a *archiveFS) Lstat(name string) (fs.FileInfo, error) {
+	_, m, err := a.find("lstat", name, false, false)
+	if err != nil {
+		return nil, err
+	}
+	return m.info, nil
+}
+
+func (a *archiveFS) ReadLink(name string) (string, error) {
+	_, m, err := a.find("readlink", name, false, false)
+	if err != nil {
+		return "", err
+	}
+	if m.link == "" {
+		return "", &fs.PathError{Op: "readlink", Path: name, Err: syscall.EINVAL}
+	}
+	return m.link, nil
+}
+
+func dirEntries(members []*member) []fs.DirEntry {
+	entries := make([]fs.DirEntry, len(members))
+	for i, m := range members {
+		entries[i] = fs.FileInfoToDirEntry(m.info)
+	}
+	return entries
+}
+
+type archiveFile struct {
+	io.ReadCloser
+	info memberInfo
+}
+
+func (f *archiveFile) Stat() (fs.FileInfo, error) {
+	return f.info, nil
+}
+
+type archiveDir struct {
+	m      *member
+	offset int
+}
+
+func (d *archiveDir) Stat() (fs.FileInfo, error) {
+	return d.m.info, nil
+}
+
+func (d *archiveDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.m.name, Err: errors.New("is a directory")}
+}
+
+func (d *archiveDir) Close() error {
+	return nil
+}
+
+func (d *archiveDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	rest := d.m.sorted[d.offset:]
+	if n > 0 && len(rest) == 0 {
+		return nil, io.EOF
+	}
+	if n > 0 && n < len(rest) {
+		rest = rest[:n]
+	}
+	d.offset += len(rest)
+	return dirEntries(rest), nil
+}
@@ -0,0 +1,84 @@
+package finder
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+)
+
+// Files reads file contents the way the walker does, so the paths of
+// entries listed inside an archive can be opened like paths on disk.
+type Files struct {
+	src *source
+}
+
+func OpenFiles(path string, opts Options) (*Files, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("resolve path %s: %w", path, err)
+	}
+
+	src, err := openSource(absPath, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Files{src: src}, nil
+}
+
+func (f *Files) Open(path string) (fs.File, error) {
+	file, err := f.src.open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", path, err)
+	}
+	return file, nil
+}
+
+func (f *Files) Close() {
+	f.src.close()
+}
+
+// Streamed reports whether path lies in a compressed archive, where any
+// read decompresses the archive from its start. Checksum reads all such
+// members in one pass, so callers should prefer it to Open for them.
+func (f *Files) Streamed(path string) bool {
+	a, _, ok, err := f.src.member(path)
+	return ok && err == nil && a.streamed()
+}
+
+func (f *Files) Checksum(ctx context.Context, path string) (string, error) {
+	if a, m, ok, err := f.src.member(path); ok && err == nil && a.streamed() {
+		sum, err := a.checksum(ctx, m)
+		if err != nil {
+			return "", fmt.Errorf("hash %s: %w", path, err)
+		}
+		return sum, nil
+	}
+
+	file, err := f.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, ctxReader{ctx: ctx, r: file}); err != nil {
+		return "", fmt.Errorf("hash %s: %w", path, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c ctxReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
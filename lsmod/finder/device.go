@@ -0,0 +1,15 @@
+package finder
+
+import "os"
+
+func (e Entry) IsDevice() bool {
+	return e.FileMode&os.ModeDevice != 0
+}
+
+func (e Entry) Major() uint32 {
+	return uint32((e.Rdev>>8)&0xfff | (e.Rdev>>32)&^0xfff)
+}
+
+func (e Entry) Minor() uint32 {
+	return uint32(e.Rdev&0xff | (e.Rdev>>12)&^0xff)
+}
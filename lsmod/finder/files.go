@@ -1,26 +1,13 @@
 package finder
 
-import (
-	"fmt"
-	"os"
-	"path/filepath"
-)
+import "context"
 
-func FindFiles(absPath string) ([]Entry, error) {
-	dirEntries, err := os.ReadDir(absPath)
+func FindFiles(ctx context.Context, absPath string, opts Options) ([]Entry, error) {
+	w, err := newWalker(ctx, absPath, opts)
 	if err != nil {
-		return nil, fmt.Errorf("read dir %s: %w", absPath, err)
+		return nil, err
 	}
+	defer w.close()
 
-	entries := make([]Entry, 0, len(dirEntries))
-	for _, de := range dirEntries {
-		entry, err := statEntry(filepath.Join(absPath, de.Name()), de.Name())
-		if err != nil {
-			return nil, err
-		}
-		entries = append(entries, entry)
-	}
-
-	sortEntries(entries)
-	return entries, nil
+	return w.listDir(absPath)
 }
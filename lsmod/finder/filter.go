@@ -0,0 +1,101 @@
+package finder
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+)
+
+type filter struct {
+	root    string
+	ignore  *ignoreMatcher
+	include []pattern
+	exclude ruleSet
+}
+
+func newFilter(root string, opts Options) (*filter, error) {
+	if !opts.GitIgnore && len(opts.Include) == 0 && len(opts.Exclude) == 0 {
+		return nil, nil
+	}
+
+	f := &filter{root: root, exclude: ruleSet{base: root}}
+	if opts.GitIgnore {
+		f.ignore = newIgnoreMatcher(root)
+	}
+
+	for _, glob := range opts.Include {
+		p, err := compileGlob(glob)
+		if err != nil {
+			return nil, err
+		}
+		f.include = append(f.include, p)
+	}
+	for _, glob := range opts.Exclude {
+		p, err := compileGlob(glob)
+		if err != nil {
+			return nil, err
+		}
+		f.exclude.patterns = append(f.exclude.patterns, p)
+	}
+	return f, nil
+}
+
+func compileGlob(glob string) (pattern, error) {
+	p, ok := parsePattern(glob)
+	if !ok {
+		return pattern{}, fmt.Errorf("empty glob %q", glob)
+	}
+	for _, seg := range p.segments {
+		if _, err := path.Match(seg, ""); err != nil {
+			return pattern{}, fmt.Errorf("invalid glob %q: %w", glob, err)
+		}
+	}
+	return p, nil
+}
+
+func (f *filter) enter(dir string) *filter {
+	if f == nil || f.ignore == nil {
+		return f
+	}
+
+	ignore := f.ignore.enter(dir)
+	if ignore == f.ignore {
+		return f
+	}
+	next := *f
+	next.ignore = ignore
+	return &next
+}
+
+func (f *filter) skip(absPath string, isDir bool) bool {
+	if f == nil {
+		return false
+	}
+
+	if f.ignore != nil {
+		if isDir && filepath.Base(absPath) == ".git" {
+			return true
+		}
+		if f.ignore.ignored(absPath, isDir) {
+			return true
+		}
+	}
+
+	if ignored, _ := f.exclude.match(absPath, isDir); ignored {
+		return true
+	}
+
+	if isDir || len(f.include) == 0 {
+		return false
+	}
+	rel, ok := relSlash(f.root, absPath)
+	if !ok {
+		return false
+	}
+	for _, p := range f.include {
+		if p.match(rel, isDir) {
+			return false
+		}
+	}
+	return true
+}
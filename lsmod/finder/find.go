@@ -1,72 +1,154 @@
 package finder
 
 import (
+	"context"
 	"fmt"
 	"os"
-	"os/user"
 	"path/filepath"
-	"strconv"
 	"syscall"
+	"time"
 )
 
 type Entry struct {
-	Name    string
-	Owner   string
-	Group   string
-	Mode    string
-	Updated string
-	IsDir   bool
+	Name     string
+	Path     string
+	Owner    string
+	Group    string
+	Mode     string
+	IsDir    bool
+	UID      uint32
+	GID      uint32
+	FileMode os.FileMode
+	ModTime  time.Time
+	ATime    time.Time
+	CTime    time.Time
+	Birth    time.Time
+	Size     int64
+	Blocks   int64
+	Links    uint64
+	Inode    uint64
+	Dev      uint64
+	Rdev     uint64
+	Err      error
+
+	LinkTarget  string
+	Broken      bool
+	TargetIsDir bool
+
+	MIME string
+
+	Xattrs       []string
+	Capabilities string
+	SELinux      string
+
+	Git GitStatus
+}
+
+func (e Entry) Time(field TimeField) time.Time {
+	switch field {
+	case TimeAtime:
+		return e.ATime
+	case TimeCtime:
+		return e.CTime
+	case TimeBirth:
+		return e.Birth
+	}
+	return e.ModTime
 }
 
-func Find(path string) ([]Entry, error) {
+func (e Entry) IsLink() bool {
+	return e.FileMode&os.ModeSymlink != 0
+}
+
+func Find(ctx context.Context, path string, opts Options) ([]Entry, error) {
 	absPath, err := filepath.Abs(path)
 	if err != nil {
 		return nil, fmt.Errorf("resolve path %s: %w", path, err)
 	}
 
-	dirs, err := FindDirs(absPath)
+	w, err := newWalker(ctx, absPath, opts)
 	if err != nil {
 		return nil, err
 	}
+	defer w.close()
+
+	info, err := w.src.stat(absPath)
+	if err != nil {
+		return nil, fmt.Errorf("stat %s: %w", absPath, err)
+	}
+	if !info.IsDir() {
+		entry, err := w.src.lstatEntry(absPath, path)
+		if err != nil {
+			return nil, err
+		}
+		w.annotate(&entry)
+		return []Entry{entry}, nil
+	}
 
-	files, err := FindFiles(absPath)
+	dirs, err := w.src.dirs(absPath)
 	if err != nil {
 		return nil, err
 	}
 
-	return append(dirs, files...), nil
-}
+	for i := range dirs {
+		w.annotate(&dirs[i])
+	}
 
-func statEntry(path, name string) (Entry, error) {
-	info, err := os.Stat(path)
+	files, err := w.listDir(absPath)
 	if err != nil {
-		return Entry{}, fmt.Errorf("stat %s: %w", path, err)
+		return nil, err
 	}
 
-	stat := info.Sys().(*syscall.Stat_t)
-
-	return Entry{
-		Name:    name,
-		Owner:   lookupUser(stat.Uid),
-		Group:   lookupGroup(stat.Gid),
-		Mode:    info.Mode().String(),
-		Updated: info.ModTime().Format("2006-01-02 15:04"),
-		IsDir:   info.IsDir(),
-	}, nil
+	return append(dirs, files...), nil
 }
 
-func lookupUser(uid uint32) string {
-	u, err := user.LookupId(strconv.Itoa(int(uid)))
-	if err != nil {
-		return strconv.Itoa(int(uid))
+func newEntry(path, name string, info os.FileInfo) Entry {
+	entry := Entry{
+		Name:     name,
+		Path:     path,
+		Mode:     ModeString(info.Mode()),
+		IsDir:    info.IsDir(),
+		FileMode: info.Mode(),
+		ModTime:  info.ModTime(),
+		Size:     info.Size(),
+	}
+
+	switch stat := info.Sys().(type) {
+	case *syscall.Stat_t:
+		entry.Owner = lookupUser(stat.Uid)
+		entry.Group = lookupGroup(stat.Gid)
+		entry.UID = stat.Uid
+		entry.GID = stat.Gid
+		entry.ATime, entry.CTime = statTimes(stat)
+		entry.Blocks = stat.Blocks
+		entry.Links = uint64(stat.Nlink)
+		entry.Inode = stat.Ino
+		entry.Dev = uint64(stat.Dev)
+		entry.Rdev = uint64(stat.Rdev)
+	case *memberStat:
+		entry.Owner = stat.Owner
+		entry.Group = stat.Group
+		entry.UID = stat.UID
+		entry.GID = stat.GID
+		entry.ATime, entry.CTime = stat.Atime, stat.Ctime
+		entry.Links = stat.Nlink
+		entry.Inode = stat.Ino
+		entry.Dev = stat.Dev
+		entry.Rdev = stat.Rdev
 	}
-	return u.Username
+	return entry
 }
 
-func lookupGroup(gid uint32) string {
-	g, err := user.LookupGroupId(strconv.Itoa(int(gid)))
-	if err != nil {
-		return strconv.Itoa(int(gid))
+func failedEntry(path, name string, isDir bool, err error) Entry {
+	return Entry{Name: name, Path: path, IsDir: isDir, Err: err}
+}
+
+func CountErrors(entries []Entry) int {
+	n := 0
+	for _, e := range entries {
+		if e.Err != nil {
+			n++
+		}
 	}
-	return g.Name
+	return n
 }
@@ -0,0 +1,177 @@
+package finder
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+type GitStatus uint8
+
+const (
+	GitStaged GitStatus = 1 << iota
+	GitModified
+	GitUntracked
+	GitIgnored
+	GitConflicted
+)
+
+func (s GitStatus) Has(flag GitStatus) bool {
+	return s&flag != 0
+}
+
+func (s GitStatus) Dirty() bool {
+	return s&^GitIgnored != 0
+}
+
+type gitIndex struct {
+	root     string
+	walked   string
+	resolved string
+	files    map[string]GitStatus
+	trees    map[string]GitStatus
+	dirs     map[string]GitStatus
+}
+
+func loadGitIndex(ctx context.Context, dir string) *gitIndex {
+	top, err := runGit(ctx, dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil
+	}
+	root := strings.TrimSpace(string(top))
+
+	// git reports the top level with symlinks resolved; paths below the
+	// walked directory are mapped onto its resolved form to match.
+	resolved, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return nil
+	}
+
+	out, err := runGit(ctx, root, "status", "--porcelain=v2", "-z",
+		"--untracked-files=all", "--ignored=matching")
+	if err != nil {
+		return nil
+	}
+
+	g := &gitIndex{
+		root:     root,
+		walked:   dir,
+		resolved: resolved,
+		files:    make(map[string]GitStatus),
+		trees:    make(map[string]GitStatus),
+		dirs:     make(map[string]GitStatus),
+	}
+	g.parse(out)
+	return g
+}
+
+func runGit(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...)
+	return cmd.Output()
+}
+
+func (g *gitIndex) parse(out []byte) {
+	records := bytes.Split(out, []byte{0})
+	for i := 0; i < len(records); i++ {
+		record := string(records[i])
+		if record == "" {
+			continue
+		}
+
+		var status GitStatus
+		var rel string
+		switch record[0] {
+		case '1':
+			status, rel = changedStatus(record, 9)
+		case '2':
+			status, rel = changedStatus(record, 10)
+			i++
+		case 'u':
+			_, rel = changedStatus(record, 11)
+			status = GitConflicted
+		case '?':
+			status, rel = GitUntracked, record[2:]
+		case '!':
+			status, rel = GitIgnored, record[2:]
+		default:
+			continue
+		}
+		g.add(rel, status)
+	}
+}
+
+func changedStatus(record string, fields int) (GitStatus, string) {
+	parts := strings.SplitN(record, " ", fields)
+	if len(parts) < fields || len(parts[1]) != 2 {
+		return 0, ""
+	}
+
+	var status GitStatus
+	if parts[1][0] != '.' {
+		status |= GitStaged
+	}
+	if parts[1][1] != '.' {
+		status |= GitModified
+	}
+	return status, parts[fields-1]
+}
+
+func (g *gitIndex) add(rel string, status GitStatus) {
+	if rel == "" || status == 0 {
+		return
+	}
+
+	if tree, isTree := strings.CutSuffix(rel, "/"); isTree {
+		g.trees[tree] |= status
+		rel = tree
+	} else {
+		g.files[rel] |= status
+	}
+
+	if !status.Dirty() {
+		return
+	}
+	for dir := path.Dir(rel); ; dir = path.Dir(dir) {
+		if dir == "." {
+			g.dirs[""] |= status
+			return
+		}
+		g.dirs[dir] |= status
+	}
+}
+
+func (g *gitIndex) status(absPath string) GitStatus {
+	if g == nil {
+		return 0
+	}
+
+	if rel, ok := below(g.walked, absPath); ok {
+		absPath = filepath.Join(g.resolved, rel)
+	}
+	rel, ok := below(g.root, absPath)
+	if !ok {
+		return 0
+	}
+	rel = filepath.ToSlash(rel)
+	if rel == "." {
+		return g.dirs[""]
+	}
+
+	status := g.files[rel] | g.dirs[rel]
+	for dir := rel; dir != "."; dir = path.Dir(dir) {
+		status |= g.trees[dir]
+	}
+	return status
+}
+
+// below returns absPath relative to dir, or false when it lies outside.
+func below(dir, absPath string) (string, bool) {
+	rel, err := filepath.Rel(dir, absPath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, "../") {
+		return "", false
+	}
+	return rel, true
+}
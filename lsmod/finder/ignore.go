@@ -0,0 +1,301 @@
+package finder
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+var ignoreFiles = []string{".gitignore", ".ignore", ".lsmodignore"}
+
+type pattern struct {
+	segments []string
+	negate   bool
+	dirOnly  bool
+}
+
+type ruleSet struct {
+	base     string
+	patterns []pattern
+}
+
+type ignoreMatcher struct {
+	sets []ruleSet
+}
+
+func parsePattern(line string) (pattern, bool) {
+	line = strings.TrimSuffix(line, "\r")
+	line = trimTrailingSpaces(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return pattern{}, false
+	}
+
+	var p pattern
+	if strings.HasPrefix(line, "!") {
+		p.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+		line = line[1:]
+	}
+
+	if strings.HasSuffix(line, "/") {
+		p.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return pattern{}, false
+	}
+
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+
+	for _, seg := range strings.Split(line, "/") {
+		p.segments = append(p.segments, strings.ReplaceAll(seg, "[!", "[^"))
+	}
+	if !anchored {
+		p.segments = append([]string{"**"}, p.segments...)
+	}
+	return p, true
+}
+
+func trimTrailingSpaces(line string) string {
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, `\ `) {
+		line = line[:len(line)-1]
+	}
+	return line
+}
+
+func (p pattern) match(rel string, isDir bool) bool {
+	if p.dirOnly && !isDir {
+		return false
+	}
+	return matchSegments(p.segments, strings.Split(rel, "/"))
+}
+
+func matchSegments(pat, parts []string) bool {
+	if len(pat) == 0 {
+		return len(parts) == 0
+	}
+
+	if pat[0] == "**" {
+		if len(pat) == 1 {
+			return len(parts) > 0
+		}
+		for i := 0; i <= len(parts); i++ {
+			if matchSegments(pat[1:], parts[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if len(parts) == 0 {
+		return false
+	}
+	if ok, err := path.Match(pat[0], parts[0]); err != nil || !ok {
+		return false
+	}
+	return matchSegments(pat[1:], parts[1:])
+}
+
+func relSlash(base, absPath string) (string, bool) {
+	rel, err := filepath.Rel(base, absPath)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, "../") {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
+
+func (rs ruleSet) match(absPath string, isDir bool) (ignored, matched bool) {
+	rel, ok := relSlash(rs.base, absPath)
+	if !ok {
+		return false, false
+	}
+
+	for i := len(rs.patterns) - 1; i >= 0; i-- {
+		if rs.patterns[i].match(rel, isDir) {
+			return !rs.patterns[i].negate, true
+		}
+	}
+	return false, false
+}
+
+func (m *ignoreMatcher) ignored(absPath string, isDir bool) bool {
+	for i := len(m.sets) - 1; i >= 0; i-- {
+		if ignored, matched := m.sets[i].match(absPath, isDir); matched {
+			return ignored
+		}
+	}
+	return false
+}
+
+func (m *ignoreMatcher) enter(dir string) *ignoreMatcher {
+	var added []ruleSet
+	for _, name := range ignoreFiles {
+		if rs, ok := loadRuleSet(filepath.Join(dir, name), dir); ok {
+			added = append(added, rs)
+		}
+	}
+	if len(added) == 0 {
+		return m
+	}
+
+	sets := append(m.sets[:len(m.sets):len(m.sets)], added...)
+	return &ignoreMatcher{sets: sets}
+}
+
+func newIgnoreMatcher(root string) *ignoreMatcher {
+	repo := findRepoRoot(root)
+	base := repo
+	if base == "" {
+		base = root
+	}
+
+	m := &ignoreMatcher{}
+	if file := globalExcludesFile(); file != "" {
+		if rs, ok := loadRuleSet(file, base); ok {
+			m.sets = append(m.sets, rs)
+		}
+	}
+	if repo == "" {
+		return m
+	}
+
+	if gitDir := resolveGitDir(repo); gitDir != "" {
+		if rs, ok := loadRuleSet(filepath.Join(gitDir, "info", "exclude"), repo); ok {
+			m.sets = append(m.sets, rs)
+		}
+	}
+
+	for _, dir := range ancestorsBetween(repo, root) {
+		m = m.enter(dir)
+	}
+	return m
+}
+
+func loadRuleSet(file, base string) (ruleSet, bool) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return ruleSet{}, false
+	}
+
+	rs := ruleSet{base: base}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if p, ok := parsePattern(scanner.Text()); ok {
+			rs.patterns = append(rs.patterns, p)
+		}
+	}
+	return rs, len(rs.patterns) > 0
+}
+
+func findRepoRoot(dir string) string {
+	for {
+		if _, err := os.Lstat(filepath.Join(dir, ".git")); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+func resolveGitDir(repo string) string {
+	dotGit := filepath.Join(repo, ".git")
+	info, err := os.Stat(dotGit)
+	if err != nil {
+		return ""
+	}
+	if info.IsDir() {
+		return dotGit
+	}
+
+	data, err := os.ReadFile(dotGit)
+	if err != nil {
+		return ""
+	}
+	gitDir, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir:")
+	if !ok {
+		return ""
+	}
+	gitDir = strings.TrimSpace(gitDir)
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(repo, gitDir)
+	}
+	return gitDir
+}
+
+func ancestorsBetween(top, dir string) []string {
+	var dirs []string
+	for dir != top {
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil
+		}
+		dir = parent
+		dirs = append([]string{dir}, dirs...)
+	}
+	return dirs
+}
+
+func globalExcludesFile() string {
+	home, _ := os.UserHomeDir()
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" && home != "" {
+		configHome = filepath.Join(home, ".config")
+	}
+
+	configs := []string{filepath.Join(configHome, "git", "config")}
+	if home != "" {
+		configs = append(configs, filepath.Join(home, ".gitconfig"))
+	}
+	if global := os.Getenv("GIT_CONFIG_GLOBAL"); global != "" {
+		configs = []string{global}
+	}
+
+	file := ""
+	for _, config := range configs {
+		if v := readExcludesFile(config); v != "" {
+			file = v
+		}
+	}
+	if file == "" && configHome != "" {
+		file = filepath.Join(configHome, "git", "ignore")
+	}
+
+	if rest, ok := strings.CutPrefix(file, "~/"); ok && home != "" {
+		file = filepath.Join(home, rest)
+	}
+	return file
+}
+
+func readExcludesFile(config string) string {
+	data, err := os.ReadFile(config)
+	if err != nil {
+		return ""
+	}
+
+	inCore := false
+	value := ""
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			inCore = strings.EqualFold(strings.Trim(line, "[] \t"), "core")
+			continue
+		}
+		if !inCore {
+			continue
+		}
+		key, v, ok := strings.Cut(line, "=")
+		if ok && strings.EqualFold(strings.TrimSpace(key), "excludesfile") {
+			value = strings.Trim(strings.TrimSpace(v), `"`)
+		}
+	}
+	return value
+}
@@ -0,0 +1,45 @@
+package finder
+
+import "os"
+
+func ModeString(mode os.FileMode) string {
+	buf := []byte("----------")
+
+	switch {
+	case mode&os.ModeDir != 0:
+		buf[0] = 'd'
+	case mode&os.ModeSymlink != 0:
+		buf[0] = 'l'
+	case mode&os.ModeNamedPipe != 0:
+		buf[0] = 'p'
+	case mode&os.ModeSocket != 0:
+		buf[0] = 's'
+	case mode&os.ModeCharDevice != 0:
+		buf[0] = 'c'
+	case mode&os.ModeDevice != 0:
+		buf[0] = 'b'
+	}
+
+	const rwx = "rwxrwxrwx"
+	for i := 0; i < 9; i++ {
+		if mode&(1<<uint(8-i)) != 0 {
+			buf[i+1] = rwx[i]
+		}
+	}
+
+	special(buf, 3, mode&os.ModeSetuid != 0, 's')
+	special(buf, 6, mode&os.ModeSetgid != 0, 's')
+	special(buf, 9, mode&os.ModeSticky != 0, 't')
+	return string(buf)
+}
+
+func special(buf []byte, i int, set bool, c byte) {
+	if !set {
+		return
+	}
+	if buf[i] == 'x' {
+		buf[i] = c
+		return
+	}
+	buf[i] = c - 'a' + 'A'
+}
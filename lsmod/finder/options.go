@@ -0,0 +1,79 @@
+package finder
+
+import "fmt"
+
+type SortBy int
+
+const (
+	SortName SortBy = iota
+	SortNatural
+	SortMtime
+	SortSize
+	SortExt
+	SortNone
+)
+
+var sortNames = map[string]SortBy{
+	"name":    SortName,
+	"natural": SortNatural,
+	"mtime":   SortMtime,
+	"size":    SortSize,
+	"ext":     SortExt,
+	"none":    SortNone,
+}
+
+func ParseSortBy(s string) (SortBy, error) {
+	by, ok := sortNames[s]
+	if !ok {
+		return SortName, fmt.Errorf("unknown sort mode %q (want name, natural, mtime, size, ext or none)", s)
+	}
+	return by, nil
+}
+
+type TimeField int
+
+const (
+	TimeMtime TimeField = iota
+	TimeAtime
+	TimeCtime
+	TimeBirth
+)
+
+var timeNames = map[string]TimeField{
+	"mtime": TimeMtime,
+	"atime": TimeAtime,
+	"ctime": TimeCtime,
+	"birth": TimeBirth,
+}
+
+func ParseTimeField(s string) (TimeField, error) {
+	field, ok := timeNames[s]
+	if !ok {
+		return TimeMtime, fmt.Errorf("unknown time %q (want mtime, atime, ctime or birth)", s)
+	}
+	return field, nil
+}
+
+type Options struct {
+	Sort           SortBy
+	Reverse        bool
+	DirsFirst      bool
+	MaxDepth       int
+	DirsOnly       bool
+	MaxEntries     int
+	GitIgnore      bool
+	Include        []string
+	Exclude        []string
+	Strict         bool
+	Follow         bool
+	Workers        int
+	Git            bool
+	Sizes          bool
+	Allocated      bool
+	Xattrs         bool
+	XattrValues    bool
+	Time           TimeField
+	Sniff          bool
+	TypeFilter     []string
+	NestedArchives bool
+}
@@ -0,0 +1,172 @@
+package finder
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type pathNode struct {
+	name     string
+	path     string
+	children map[string]*pathNode
+	order    []string
+}
+
+func (n *pathNode) child(name, path string) *pathNode {
+	if c, ok := n.children[name]; ok {
+		return c
+	}
+	if n.children == nil {
+		n.children = make(map[string]*pathNode)
+	}
+	c := &pathNode{name: name, path: path}
+	n.children[name] = c
+	n.order = append(n.order, name)
+	return c
+}
+
+func TreeFromPaths(ctx context.Context, paths []string, opts Options) (TreeNode, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return TreeNode{}, fmt.Errorf("resolve working directory: %w", err)
+	}
+
+	w, err := newWalker(ctx, cwd, opts)
+	if err != nil {
+		return TreeNode{}, err
+	}
+	defer w.close()
+
+	root := pathRoot(cwd, paths)
+	for _, p := range paths {
+		abs := absOf(cwd, p)
+		rel := filepath.Clean(p)
+		if root.path != "." {
+			rel, _ = filepath.Rel(root.path, abs)
+		}
+		if rel == "." || w.filter.skip(abs, false) {
+			continue
+		}
+
+		node := root
+		for _, part := range strings.Split(rel, "/") {
+			node = node.child(part, filepath.Join(node.path, part))
+		}
+	}
+
+	tree := w.virtualTree(root, cwd)
+	if err := ctx.Err(); err != nil {
+		return TreeNode{}, err
+	}
+
+	if opts.Sizes {
+		aggregate(&tree, make(map[fileID]bool), opts.Follow)
+	}
+	shape(&tree, 0, opts)
+	return tree, nil
+}
+
+func EntriesFromPaths(ctx context.Context, paths []string, opts Options) ([]Entry, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("resolve working directory: %w", err)
+	}
+
+	w, err := newWalker(ctx, cwd, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer w.close()
+
+	entries := make([]Entry, 0, len(paths))
+	for _, p := range paths {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		entry, err := w.src.lstatEntry(absOf(cwd, p), p)
+		if err != nil {
+			if opts.Strict {
+				return nil, err
+			}
+			entry = failedEntry(absOf(cwd, p), p, false, err)
+		}
+		if w.filter.skip(entry.Path, entry.IsDir) {
+			continue
+		}
+		w.annotate(&entry)
+		entries = append(entries, entry)
+	}
+
+	sortEntries(entries, opts)
+	return entries, nil
+}
+
+func (w *walker) virtualTree(n *pathNode, cwd string) TreeNode {
+	abs := absOf(cwd, n.path)
+	entry, err := w.src.lstatEntry(abs, n.name)
+	if err != nil || (len(n.children) > 0 && !entry.IsDir) {
+		entry = virtualEntry(abs, n.name, len(n.children) > 0)
+	}
+	w.annotate(&entry)
+
+	node := TreeNode{Entry: entry}
+	for _, name := range n.order {
+		node.Children = append(node.Children, w.virtualTree(n.children[name], cwd))
+	}
+	return node
+}
+
+func virtualEntry(path, name string, isDir bool) Entry {
+	mode := os.FileMode(0o644)
+	if isDir {
+		mode = os.ModeDir | 0o755
+	}
+	return Entry{
+		Name:     name,
+		Path:     path,
+		Mode:     ModeString(mode),
+		IsDir:    isDir,
+		FileMode: mode,
+	}
+}
+
+// pathRoot picks the node the listed paths hang from: "." while every path
+// stays below the working directory, otherwise the deepest directory that
+// holds all of them, so absolute, relative and ../ paths share one tree.
+func pathRoot(cwd string, paths []string) *pathNode {
+	outside := false
+	for _, p := range paths {
+		clean := filepath.Clean(p)
+		if filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") {
+			outside = true
+			break
+		}
+	}
+	if !outside {
+		return &pathNode{name: ".", path: "."}
+	}
+
+	base := ""
+	for _, p := range paths {
+		dir := filepath.Dir(absOf(cwd, p))
+		if base == "" {
+			base = dir
+			continue
+		}
+		for base != "/" && base != dir && !strings.HasPrefix(dir, base+"/") {
+			base = filepath.Dir(base)
+		}
+	}
+	return &pathNode{name: base, path: base}
+}
+
+func absOf(cwd, path string) string {
+	if filepath.IsAbs(path) {
+		return filepath.Clean(path)
+	}
+	return filepath.Join(cwd, path)
+}
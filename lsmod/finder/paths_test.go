@@ -0,0 +1,66 @@
+package finder
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTreeFromPathsMaxEntries(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "a"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	paths := []string{"a/x1", "a/x2", "a/x3"}
+	for _, p := range paths {
+		if err := os.WriteFile(filepath.Join(dir, p), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Chdir(dir)
+
+	for _, sizes := range []bool{false, true} {
+		opts := Options{Sort: SortName, MaxEntries: 1, Sizes: sizes}
+		tree, err := TreeFromPaths(context.Background(), paths, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(tree.Children) != 1 || tree.Children[0].Name != "a" {
+			t.Fatalf("sizes=%v: root children = %+v, want only a", sizes, tree.Children)
+		}
+		a := tree.Children[0].Children
+		if len(a) != 2 || a[0].Name != "x1" || a[1].Omitted == nil || a[1].Omitted.Files != 2 {
+			t.Fatalf("sizes=%v: a children = %+v, want x1 and 2 omitted files", sizes, a)
+		}
+		if (a[1].Usage != nil) != sizes {
+			t.Errorf("sizes=%v: omitted usage = %v", sizes, a[1].Usage)
+		}
+	}
+}
+
+func TestTreeFromPathsMixed(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "a", "b"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(filepath.Join(dir, "a"))
+
+	paths := []string{"b", filepath.Join(dir, "a", "c"), "../d"}
+	tree, err := TreeFromPaths(context.Background(), paths, Options{Sort: SortName})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tree.Name != dir {
+		t.Fatalf("root = %q, want %q", tree.Name, dir)
+	}
+	if len(tree.Children) != 2 || tree.Children[0].Name != "a" || tree.Children[1].Name != "d" {
+		t.Fatalf("root children = %+v, want a and d", tree.Children)
+	}
+	a := tree.Children[0].Children
+	if len(a) != 2 || a[0].Name != "b" || a[1].Name != "c" {
+		t.Errorf("a children = %+v, want b and c", a)
+	}
+}
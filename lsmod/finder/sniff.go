@@ -0,0 +1,281 @@
+package finder
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+)
+
+const sniffLen = 512
+
+var archiveTypes = map[string]bool{
+	"application/zip":              true,
+	"application/gzip":             true,
+	"application/x-gzip":           true,
+	"application/x-tar":            true,
+	"application/x-bzip2":          true,
+	"application/x-xz":             true,
+	"application/zstd":             true,
+	"application/x-7z-compressed":  true,
+	"application/x-rar-compressed": true,
+	"application/java-archive":     true,
+}
+
+var executableTypes = map[string]bool{
+	"application/x-executable":     true,
+	"application/x-pie-executable": true,
+	"application/x-dosexec":        true,
+	"application/x-mach-binary":    true,
+}
+
+var interpreters = map[string]string{
+	"sh":      "text/x-shellscript",
+	"bash":    "text/x-shellscript",
+	"zsh":     "text/x-shellscript",
+	"dash":    "text/x-shellscript",
+	"ksh":     "text/x-shellscript",
+	"python":  "text/x-python",
+	"python3": "text/x-python",
+	"perl":    "text/x-perl",
+	"ruby":    "text/x-ruby",
+	"node":    "text/javascript",
+	"php":     "text/x-php",
+}
+
+var typeCategories = []string{"binary", "text", "executable", "script", "image", "archive"}
+
+func CheckTypeFilter(filters []string) error {
+	for _, f := range filters {
+		if strings.Contains(f, "/") {
+			if _, err := path.Match(f, ""); err != nil {
+				return fmt.Errorf("invalid type filter %q: %w", f, err)
+			}
+			continue
+		}
+		if !isCategory(f) {
+			return fmt.Errorf("unknown type filter %q (want %s or a MIME type like image/*)",
+				f, strings.Join(typeCategories, ", "))
+		}
+	}
+	return nil
+}
+
+func isCategory(name string) bool {
+	for _, c := range typeCategories {
+		if c == name {
+			return true
+		}
+	}
+	return false
+}
+
+func sniff(entry *Entry, src *source) {
+	switch {
+	case entry.IsDir:
+		entry.MIME = "inode/directory"
+	case entry.IsLink():
+		entry.MIME = "inode/symlink"
+	case entry.FileMode&os.ModeNamedPipe != 0:
+		entry.MIME = "inode/fifo"
+	case entry.FileMode&os.ModeSocket != 0:
+		entry.MIME = "inode/socket"
+	case entry.FileMode&os.ModeCharDevice != 0:
+		entry.MIME = "inode/chardevice"
+	case entry.FileMode&os.ModeDevice != 0:
+		entry.MIME = "inode/blockdevice"
+	case entry.Size == 0:
+		entry.MIME = "inode/x-empty"
+	default:
+		head, err := readHead(src, entry.Path)
+		if err != nil {
+			return
+		}
+		entry.MIME = detect(head, entry.FileMode)
+	}
+}
+
+func readHead(src *source, path string) ([]byte, error) {
+	f, err := src.open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	buf := make([]byte, sniffLen)
+	n, err := io.ReadFull(f, buf)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+	return buf[:n], nil
+}
+
+func detect(head []byte, mode os.FileMode) string {
+	switch {
+	case bytes.HasPrefix(head, []byte("\x7fELF")):
+		return elfType(head, mode)
+	case bytes.HasPrefix(head, []byte("#!")):
+		return scriptType(head)
+	case bytes.HasPrefix(head, []byte("MZ")):
+		return "application/x-dosexec"
+	case machO(head):
+		return "application/x-mach-binary"
+	case bytes.HasPrefix(head, []byte("\xef\xbb\xbf")):
+		return "text/plain; charset=utf-8"
+	case bytes.HasPrefix(head, []byte("\xff\xfe")):
+		return "text/plain; charset=utf-16le"
+	case bytes.HasPrefix(head, []byte("\xfe\xff")):
+		return "text/plain; charset=utf-16be"
+	case len(head) > 262 && string(head[257:262]) == "ustar":
+		return "application/x-tar"
+	case bytes.HasPrefix(head, []byte("BZh")):
+		return "application/x-bzip2"
+	case bytes.HasPrefix(head, []byte("\xfd7zXZ\x00")):
+		return "application/x-xz"
+	case bytes.HasPrefix(head, []byte("\x28\xb5\x2f\xfd")):
+		return "application/zstd"
+	case bytes.HasPrefix(head, []byte("7z\xbc\xaf\x27\x1c")):
+		return "application/x-7z-compressed"
+	}
+
+	mime := http.DetectContentType(head)
+	if mime == "application/x-gzip" {
+		return "application/gzip"
+	}
+	return mime
+}
+
+func elfType(head []byte, mode os.FileMode) string {
+	if len(head) < 18 {
+		return "application/x-executable"
+	}
+
+	var kind uint16
+	if head[5] == 2 {
+		kind = uint16(head[16])<<8 | uint16(head[17])
+	} else {
+		kind = uint16(head[17])<<8 | uint16(head[16])
+	}
+	switch kind {
+	case 1:
+		return "application/x-object"
+	case 3:
+		if mode&0o111 != 0 {
+			return "application/x-pie-executable"
+		}
+		return "application/x-sharedlib"
+	case 4:
+		return "application/x-coredump"
+	}
+	return "application/x-executable"
+}
+
+func machO(head []byte) bool {
+	for _, magic := range []string{"\xfe\xed\xfa\xce", "\xfe\xed\xfa\xcf", "\xce\xfa\xed\xfe", "\xcf\xfa\xed\xfe", "\xca\xfe\xba\xbe"} {
+		if bytes.HasPrefix(head, []byte(magic)) {
+			return true
+		}
+	}
+	return false
+}
+
+func scriptType(head []byte) string {
+	line := head[2:]
+	if i := bytes.IndexByte(line, '\n'); i >= 0 {
+		line = line[:i]
+	}
+
+	fields := strings.Fields(string(line))
+	if len(fields) == 0 {
+		return "text/x-script"
+	}
+	interp := path.Base(fields[0])
+	if interp == "env" {
+		for _, f := range fields[1:] {
+			if !strings.HasPrefix(f, "-") {
+				interp = f
+				break
+			}
+		}
+	}
+	interp = strings.TrimRight(interp, "0123456789.")
+	if mime, ok := interpreters[interp]; ok {
+		return mime
+	}
+	if mime, ok := interpreters[interp+"3"]; ok {
+		return mime
+	}
+	return "text/x-script"
+}
+
+func (e Entry) IsText() bool {
+	return strings.HasPrefix(e.MIME, "text/") || strings.Contains(e.MIME, "charset=") ||
+		e.MIME == "application/json"
+}
+
+func (e Entry) IsBinary() bool {
+	return e.MIME != "" && !strings.HasPrefix(e.MIME, "inode/") && !e.IsText()
+}
+
+func (e Entry) matchesType(filters []string) bool {
+	mime, _, _ := strings.Cut(e.MIME, ";")
+	for _, f := range filters {
+		switch f {
+		case "binary":
+			if e.IsBinary() {
+				return true
+			}
+		case "text":
+			if e.IsText() {
+				return true
+			}
+		case "executable":
+			if executableTypes[mime] || e.isScript() {
+				return true
+			}
+		case "script":
+			if e.isScript() {
+				return true
+			}
+		case "image":
+			if strings.HasPrefix(mime, "image/") {
+				return true
+			}
+		case "archive":
+			if archiveTypes[mime] {
+				return true
+			}
+		default:
+			if ok, _ := path.Match(f, mime); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (e Entry) isScript() bool {
+	for _, mime := range interpreters {
+		if e.MIME == mime {
+			return true
+		}
+	}
+	return e.MIME == "text/x-script"
+}
+
+func pruneUnmatched(node *TreeNode) {
+	kept := node.Children[:0]
+	for _, child := range node.Children {
+		if child.IsDir && child.Err == nil && child.Children != nil {
+			pruneUnmatched(&child)
+			if len(child.Children) == 0 {
+				continue
+			}
+		}
+		kept = append(kept, child)
+	}
+	node.Children = kept
+}
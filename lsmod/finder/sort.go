@@ -1,24 +1,119 @@
 package finder
 
 import (
-	"os"
+	"path/filepath"
 	"sort"
+	"strings"
 )
 
-func sortEntries(entries []Entry) {
-	sort.Slice(entries, func(i, j int) bool {
-		if entries[i].IsDir != entries[j].IsDir {
-			return entries[i].IsDir
-		}
-		return entries[i].Name < entries[j].Name
+func sortEntries(entries []Entry, opts Options) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		return less(entries[i], entries[j], opts)
 	})
 }
 
-func sortDirEntries(entries []os.DirEntry) {
-	sort.Slice(entries, func(i, j int) bool {
-		if entries[i].IsDir() != entries[j].IsDir() {
-			return entries[i].IsDir()
-		}
-		return entries[i].Name() < entries[j].Name()
+func sortNodes(nodes []TreeNode, opts Options) {
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return less(nodes[i].sortEntry(opts), nodes[j].sortEntry(opts), opts)
 	})
 }
+
+func (n TreeNode) sortEntry(opts Options) Entry {
+	e := n.Entry
+	if n.Usage != nil {
+		e.Size = n.Usage.Apparent
+		if opts.Allocated {
+			e.Size = n.Usage.Allocated
+		}
+	}
+	return e
+}
+
+func less(a, b Entry, opts Options) bool {
+	if opts.DirsFirst && a.IsDir != b.IsDir {
+		return a.IsDir
+	}
+	if opts.Sort == SortNone {
+		return false
+	}
+	if opts.Reverse {
+		return compare(b, a, opts) < 0
+	}
+	return compare(a, b, opts) < 0
+}
+
+func compare(a, b Entry, opts Options) int {
+	switch opts.Sort {
+	case SortNatural:
+		return naturalCompare(a.Name, b.Name)
+	case SortMtime:
+		if c := b.Time(opts.Time).Compare(a.Time(opts.Time)); c != 0 {
+			return c
+		}
+	case SortSize:
+		if a.Size != b.Size {
+			if a.Size > b.Size {
+				return -1
+			}
+			return 1
+		}
+	case SortExt:
+		if c := strings.Compare(extension(a.Name), extension(b.Name)); c != 0 {
+			return c
+		}
+	}
+	return strings.Compare(a.Name, b.Name)
+}
+
+func extension(name string) string {
+	if strings.LastIndexByte(name, '.') <= 0 {
+		return ""
+	}
+	return filepath.Ext(name)
+}
+
+func naturalCompare(a, b string) int {
+	for a != "" && b != "" {
+		if isDigit(a[0]) && isDigit(b[0]) {
+			numA, restA := splitDigits(a)
+			numB, restB := splitDigits(b)
+			if c := compareNumbers(numA, numB); c != 0 {
+				return c
+			}
+			a, b = restA, restB
+			continue
+		}
+		if a[0] != b[0] {
+			if a[0] < b[0] {
+				return -1
+			}
+			return 1
+		}
+		a, b = a[1:], b[1:]
+	}
+	return len(a) - len(b)
+}
+
+func compareNumbers(a, b string) int {
+	trimmedA := strings.TrimLeft(a, "0")
+	trimmedB := strings.TrimLeft(b, "0")
+	if len(trimmedA) != len(trimmedB) {
+		return len(trimmedA) - len(trimmedB)
+	}
+	if c := strings.Compare(trimmedA, trimmedB); c != 0 {
+		return c
+	}
+	return len(a) - len(b)
+}
+
+func splitDigits(s string) (string, string) {
+	i := 0
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+	return s[:i], s[i:]
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
@@ -0,0 +1,251 @@
+package finder
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+type fileSystem interface {
+	fs.ReadDirFS
+	fs.StatFS
+	fs.ReadLinkFS
+}
+
+// diskFS serves the host filesystem with names relative to "/". Unlike
+// os.DirFS it returns directory entries unsorted; the walker sorts them.
+type diskFS struct{}
+
+func (diskFS) path(name string) string {
+	if name == "." {
+		return "/"
+	}
+	return "/" + name
+}
+
+func (d diskFS) Open(name string) (fs.File, error) {
+	return os.Open(d.path(name))
+}
+
+func (d diskFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	f, err := os.Open(d.path(name))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return f.ReadDir(-1)
+}
+
+func (d diskFS) Stat(name string) (fs.FileInfo, error) {
+	return os.Stat(d.path(name))
+}
+
+func (d diskFS) Lstat(name string) (fs.FileInfo, error) {
+	return os.Lstat(d.path(name))
+}
+
+func (d diskFS) ReadLink(name string) (string, error) {
+	return os.Readlink(d.path(name))
+}
+
+// source maps absolute paths onto the filesystem mounted at root. Paths
+// outside root fall through to the disk.
+type source struct {
+	fsys    fileSystem
+	root    string
+	archive *archiveFS
+	closer  io.Closer
+}
+
+var disk = &source{fsys: diskFS{}, root: "/"}
+
+func openSource(path string, opts Options) (*source, error) {
+	for dir := path; ; {
+		info, err := os.Stat(dir)
+		if err == nil {
+			format := archiveFormatOf(dir)
+			if !info.Mode().IsRegular() || format == formatNone {
+				return disk, nil
+			}
+			return openArchiveSource(dir, info, format, archiveOptions{
+				nested: opts.NestedArchives,
+				heads:  opts.Sniff || len(opts.TypeFilter) > 0,
+			})
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return disk, nil
+		}
+		dir = parent
+	}
+}
+
+func openArchiveSource(path string, info fs.FileInfo, format archiveFormat, opts archiveOptions) (*source, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open archive %s: %w", path, err)
+	}
+
+	a, err := newArchiveFS(f, info.Size(), format, rootInfo(info), opts)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("read archive %s: %w", path, err)
+	}
+	return &source{fsys: a, root: path, archive: a, closer: f}, nil
+}
+
+func rootInfo(info fs.FileInfo) memberInfo {
+	perm := info.Mode().Perm()
+	root := memberInfo{
+		name:  info.Name(),
+		mode:  fs.ModeDir | perm | perm&0o444>>2,
+		mtime: info.ModTime(),
+		stat:  &memberStat{Nlink: 1, Atime: info.ModTime(), Ctime: info.ModTime()},
+	}
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		root.stat.UID, root.stat.GID = stat.Uid, stat.Gid
+		root.stat.Owner, root.stat.Group = lookupUser(stat.Uid), lookupGroup(stat.Gid)
+	}
+	return root
+}
+
+func (s *source) virtual() bool {
+	return s.archive != nil
+}
+
+func (s *source) close() {
+	if s.archive != nil {
+		s.archive.close()
+	}
+	if s.closer != nil {
+		s.closer.Close()
+	}
+}
+
+func (s *source) locate(path string) (*source, string) {
+	if path == s.root {
+		return s, "."
+	}
+	prefix := strings.TrimSuffix(s.root, "/") + "/"
+	if name, ok := strings.CutPrefix(path, prefix); ok {
+		return s, name
+	}
+	return disk, strings.TrimPrefix(path, "/")
+}
+
+func (s *source) pathError(err error, path string) error {
+	var pe *fs.PathError
+	if s.virtual() && errors.As(err, &pe) {
+		return &fs.PathError{Op: pe.Op, Path: path, Err: pe.Err}
+	}
+	return err
+}
+
+func (s *source) stat(path string) (fs.FileInfo, error) {
+	src, name := s.locate(path)
+	info, err := src.fsys.Stat(name)
+	return info, src.pathError(err, path)
+}
+
+func (s *source) lstat(path string) (fs.FileInfo, error) {
+	src, name := s.locate(path)
+	info, err := src.fsys.Lstat(name)
+	return info, src.pathError(err, path)
+}
+
+func (s *source) readLink(path string) (string, error) {
+	src, name := s.locate(path)
+	target, err := src.fsys.ReadLink(name)
+	return target, src.pathError(err, path)
+}
+
+func (s *source) open(path string) (fs.File, error) {
+	src, name := s.locate(path)
+	f, err := src.fsys.Open(name)
+	return f, src.pathError(err, path)
+}
+
+// member finds the archive and member behind path, following symlinks
+// and nested archives. It reports false for paths on disk.
+func (s *source) member(path string) (*archiveFS, *member, bool, error) {
+	src, name := s.locate(path)
+	if src.archive == nil {
+		return nil, nil, false, nil
+	}
+	a, m, err := src.archive.find("open", name, true, true)
+	return a, m, true, src.pathError(err, path)
+}
+
+func (s *source) readDir(path string) ([]fs.DirEntry, error) {
+	src, name := s.locate(path)
+	entries, err := src.fsys.ReadDir(name)
+	if err != nil {
+		return nil, fmt.Errorf("read dir %s: %w", path, src.pathError(err, path))
+	}
+	return entries, nil
+}
+
+func (s *source) statEntry(path, name string) (Entry, error) {
+	info, err := s.stat(path)
+	if err != nil {
+		return Entry{}, fmt.Errorf("stat %s: %w", path, err)
+	}
+	return newEntry(path, name, info), nil
+}
+
+func (s *source) lstatEntry(path, name string) (Entry, error) {
+	info, err := s.lstat(path)
+	if err != nil {
+		return Entry{}, fmt.Errorf("lstat %s: %w", path, err)
+	}
+
+	entry := newEntry(path, name, info)
+	if entry.IsLink() {
+		s.resolveLink(path, &entry)
+	}
+	return entry, nil
+}
+
+func (s *source) resolveLink(path string, entry *Entry) {
+	entry.LinkTarget, _ = s.readLink(path)
+
+	target, err := s.stat(path)
+	if err != nil {
+		entry.Broken = true
+		return
+	}
+	entry.TargetIsDir = target.IsDir()
+}
+
+func (s *source) dirID(path string, entry Entry) (fileID, error) {
+	if !entry.IsLink() {
+		return fileID{dev: entry.Dev, ino: entry.Inode}, nil
+	}
+
+	info, err := s.stat(path)
+	if err != nil {
+		return fileID{}, fmt.Errorf("stat %s: %w", path, err)
+	}
+	target := newEntry(path, "", info)
+	return fileID{dev: target.Dev, ino: target.Inode}, nil
+}
+
+func (s *source) dirs(absPath string) ([]Entry, error) {
+	current, err := s.statEntry(absPath, ".")
+	if err != nil {
+		return nil, fmt.Errorf("read current dir: %w", err)
+	}
+
+	parent, err := s.statEntry(filepath.Dir(absPath), "..")
+	if err != nil {
+		return nil, fmt.Errorf("read parent dir: %w", err)
+	}
+
+	return []Entry{current, parent}, nil
+}
@@ -0,0 +1,73 @@
+package finder
+
+import (
+	"runtime"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+const (
+	atFDCWD           = -100
+	atSymlinkNoFollow = 0x100
+	statxBtime        = 0x800
+)
+
+type statxTimestamp struct {
+	Sec  int64
+	Nsec uint32
+	_    int32
+}
+
+type statxBuf struct {
+	Mask           uint32
+	Blksize        uint32
+	Attributes     uint64
+	Nlink          uint32
+	UID            uint32
+	GID            uint32
+	Mode           uint16
+	_              uint16
+	Ino            uint64
+	Size           uint64
+	Blocks         uint64
+	AttributesMask uint64
+	Atime          statxTimestamp
+	Btime          statxTimestamp
+	_              [256 - 96]byte
+}
+
+var sysStatx = map[string]uintptr{
+	"amd64":   332,
+	"386":     383,
+	"arm":     397,
+	"arm64":   291,
+	"riscv64": 291,
+	"loong64": 291,
+	"ppc64":   383,
+	"ppc64le": 383,
+	"s390x":   379,
+}[runtime.GOARCH]
+
+func statTimes(stat *syscall.Stat_t) (atime, ctime time.Time) {
+	return time.Unix(stat.Atim.Unix()), time.Unix(stat.Ctim.Unix())
+}
+
+func birthTime(path string) time.Time {
+	if sysStatx == 0 {
+		return time.Time{}
+	}
+	p, err := syscall.BytePtrFromString(path)
+	if err != nil {
+		return time.Time{}
+	}
+
+	var stx statxBuf
+	dirfd := atFDCWD
+	_, _, errno := syscall.Syscall6(sysStatx, uintptr(dirfd), uintptr(unsafe.Pointer(p)),
+		atSymlinkNoFollow, statxBtime, uintptr(unsafe.Pointer(&stx)), 0)
+	if errno != 0 || stx.Mask&statxBtime == 0 {
+		return time.Time{}
+	}
+	return time.Unix(stx.Btime.Sec, int64(stx.Btime.Nsec))
+}
@@ -0,0 +1,16 @@
+//go:build !linux
+
+package finder
+
+import (
+	"syscall"
+	"time"
+)
+
+func statTimes(stat *syscall.Stat_t) (atime, ctime time.Time) {
+	return time.Time{}, time.Time{}
+}
+
+func birthTime(path string) time.Time {
+	return time.Time{}
+}
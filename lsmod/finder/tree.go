@@ -1,60 +1,123 @@
 package finder
 
 import (
+	"context"
 	"fmt"
-	"os"
 	"path/filepath"
 )
 
 type TreeNode struct {
-	Name     string
-	IsDir    bool
+	Entry
 	Children []TreeNode
+	Omitted  *Omitted
+	Loop     bool
+	Usage    *Usage
 }
 
-func Tree(path string) (TreeNode, error) {
+type Omitted struct {
+	Files int
+	Dirs  int
+}
+
+func (n TreeNode) Collapsed() bool {
+	return n.Omitted != nil
+}
+
+func (n TreeNode) ErrorCount() int {
+	count := 0
+	if n.Err != nil {
+		count++
+	}
+	for _, child := range n.Children {
+		count += child.ErrorCount()
+	}
+	return count
+}
+
+func Tree(ctx context.Context, path string, opts Options) (TreeNode, error) {
 	absPath, err := filepath.Abs(path)
 	if err != nil {
 		return TreeNode{}, fmt.Errorf("resolve path %s: %w", path, err)
 	}
 
-	info, err := os.Stat(absPath)
+	w, err := newWalker(ctx, absPath, opts)
 	if err != nil {
-		return TreeNode{}, fmt.Errorf("stat %s: %w", absPath, err)
-	}
-
-	return buildTree(absPath, info.Name(), info.IsDir())
-}
-
-func buildTree(path, name string, isDir bool) (TreeNode, error) {
-	node := TreeNode{Name: name, IsDir: isDir}
-	if !isDir {
-		return node, nil
+		return TreeNode{}, err
 	}
+	defer w.close()
 
-	entries, err := os.ReadDir(path)
+	entry, err := w.src.statEntry(absPath, filepath.Base(absPath))
 	if err != nil {
-		return TreeNode{}, fmt.Errorf("read dir %s: %w", path, err)
+		return TreeNode{}, err
 	}
 
-	sortDirEntries(entries)
-	children, err := buildChildren(path, entries)
+	w.annotate(&entry)
+	node, err := w.buildTree(absPath, entry, 0, w.filter, nil)
 	if err != nil {
+		return TreeNode{}, w.err(err)
+	}
+	if err := ctx.Err(); err != nil {
 		return TreeNode{}, err
 	}
 
-	node.Children = children
+	if len(opts.TypeFilter) > 0 {
+		pruneUnmatched(&node)
+	}
+	if opts.Sizes {
+		aggregate(&node, make(map[fileID]bool), opts.Follow)
+		shape(&node, 0, opts)
+	}
 	return node, nil
 }
 
-func buildChildren(path string, entries []os.DirEntry) ([]TreeNode, error) {
-	children := make([]TreeNode, 0, len(entries))
-	for _, e := range entries {
-		child, err := buildTree(filepath.Join(path, e.Name()), e.Name(), e.IsDir())
-		if err != nil {
-			return nil, err
+func shape(node *TreeNode, depth int, opts Options) {
+	if opts.MaxDepth > 0 && depth >= opts.MaxDepth {
+		node.Children = nil
+		return
+	}
+
+	children := node.Children
+	if opts.DirsOnly {
+		children = make([]TreeNode, 0, len(node.Children))
+		for _, child := range node.Children {
+			if child.IsDir || child.TargetIsDir {
+				children = append(children, child)
+			}
+		}
+	}
+
+	sortNodes(children, opts)
+	kept, omitted := truncate(children, opts.MaxEntries)
+	for i := range kept {
+		shape(&kept[i], depth+1, opts)
+	}
+
+	if omitted != nil {
+		rest := TreeNode{Omitted: omitted}
+		if opts.Sizes {
+			var usage Usage
+			for _, n := range children[len(kept):] {
+				usage.add(*n.Usage)
+			}
+			rest.Usage = &usage
+		}
+		kept = append(kept[:len(kept):len(kept)], rest)
+	}
+	node.Children = kept
+}
+
+func truncate(nodes []TreeNode, limit int) ([]TreeNode, *Omitted) {
+	if limit <= 0 || len(nodes) <= limit {
+		return nodes, nil
+	}
+
+	omitted := &Omitted{}
+	for _, n := range nodes[limit:] {
+		if n.IsDir {
+			omitted.Dirs++
+		} else {
+			omitted.Files++
 		}
-		children = append(children, child)
 	}
-	return children, nil
+	return nodes[:limit], omitted
 }
@@ -0,0 +1,38 @@
+package finder
+
+type Usage struct {
+	Apparent  int64
+	Allocated int64
+	Files     int
+}
+
+func (u *Usage) add(other Usage) {
+	u.Apparent += other.Apparent
+	u.Allocated += other.Allocated
+	u.Files += other.Files
+}
+
+// aggregate sums the usage below node. A file is counted once per inode
+// when it has several hard links, or, with follow, when a symlinked
+// directory leads to it a second time.
+func aggregate(node *TreeNode, seen map[fileID]bool, follow bool) Usage {
+	var usage Usage
+	if node.Err == nil || node.Mode != "" {
+		id := fileID{dev: node.Dev, ino: node.Inode}
+		shared := node.Links > 1 || (follow && node.Inode != 0)
+		if node.IsDir || !shared || !seen[id] {
+			seen[id] = true
+			usage.Apparent = node.Size
+			usage.Allocated = node.Blocks * 512
+			if !node.IsDir {
+				usage.Files = 1
+			}
+		}
+	}
+
+	for i := range node.Children {
+		usage.add(aggregate(&node.Children[i], seen, follow))
+	}
+	node.Usage = &usage
+	return usage
+}
@@ -0,0 +1,36 @@
+package finder
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTreeSizesFollowCountsFilesOnce(t *testing.T) {
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, "real"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "real", "f"), make([]byte, 10000), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("real", filepath.Join(root, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	tree, err := Tree(context.Background(), root, Options{Sort: SortName, Sizes: true, Follow: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var files int64
+	for _, child := range tree.Children {
+		for _, grandchild := range child.Children {
+			files += grandchild.Usage.Apparent
+		}
+	}
+	if files != 10000 {
+		t.Errorf("f counted for %d bytes through both paths, want 10000", files)
+	}
+}
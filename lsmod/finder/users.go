@@ -0,0 +1,38 @@
+package finder
+
+import (
+	"os/user"
+	"strconv"
+	"sync"
+)
+
+var (
+	userNames  sync.Map
+	groupNames sync.Map
+)
+
+func lookupUser(uid uint32) string {
+	if name, ok := userNames.Load(uid); ok {
+		return name.(string)
+	}
+
+	name := strconv.Itoa(int(uid))
+	if u, err := user.LookupId(name); err == nil {
+		name = u.Username
+	}
+	userNames.Store(uid, name)
+	return name
+}
+
+func lookupGroup(gid uint32) string {
+	if name, ok := groupNames.Load(gid); ok {
+		return name.(string)
+	}
+
+	name := strconv.Itoa(int(gid))
+	if g, err := user.LookupGroupId(name); err == nil {
+		name = g.Name
+	}
+	groupNames.Store(gid, name)
+	return name
+}
@@ -0,0 +1,320 @@
+package finder
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"sync"
+)
+
+const statBatch = 256
+
+type walker struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	opts   Options
+	tokens chan struct{}
+	filter *filter
+	git    *gitIndex
+	src    *source
+
+	failOnce sync.Once
+	failErr  error
+}
+
+type fileID struct {
+	dev uint64
+	ino uint64
+}
+
+type visit struct {
+	id     fileID
+	parent *visit
+}
+
+func (v *visit) contains(id fileID) bool {
+	for ; v != nil; v = v.parent {
+		if v.id == id {
+			return true
+		}
+	}
+	return false
+}
+
+func newWalker(ctx context.Context, root string, opts Options) (*walker, error) {
+	src, err := openSource(root, opts)
+	if err != nil {
+		return nil, err
+	}
+	if src.virtual() {
+		opts.Git, opts.GitIgnore, opts.Xattrs = false, false, false
+	}
+
+	f, err := newFilter(root, opts)
+	if err != nil {
+		src.close()
+		return nil, err
+	}
+
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = 4 * runtime.GOMAXPROCS(0)
+	}
+
+	w := &walker{
+		opts:   opts,
+		tokens: make(chan struct{}, workers-1),
+		filter: f,
+		src:    src,
+	}
+	if opts.Git {
+		w.git = loadGitIndex(ctx, root)
+	}
+	w.ctx, w.cancel = context.WithCancel(ctx)
+	return w, nil
+}
+
+func (w *walker) annotate(entry *Entry) {
+	entry.Git = w.git.status(entry.Path)
+	if w.opts.Xattrs && entry.Err == nil {
+		readAttributes(entry, w.opts.XattrValues)
+	}
+	if w.opts.Time == TimeBirth && entry.Err == nil && !w.src.virtual() {
+		entry.Birth = birthTime(entry.Path)
+	}
+	if (w.opts.Sniff || len(w.opts.TypeFilter) > 0) && entry.Err == nil {
+		sniff(entry, w.src)
+	}
+}
+
+func (w *walker) listDir(path string) ([]Entry, error) {
+	entries, _, err := w.readEntries(path, w.filter.enter(path), 0)
+	if err != nil {
+		return nil, w.err(err)
+	}
+
+	sortEntries(entries, w.opts)
+	return entries, nil
+}
+
+func (w *walker) close() {
+	w.cancel()
+	w.src.close()
+}
+
+func (w *walker) fail(err error) {
+	w.failOnce.Do(func() {
+		w.failErr = err
+		w.cancel()
+	})
+}
+
+func (w *walker) err(err error) error {
+	if w.failErr != nil {
+		return w.failErr
+	}
+	return err
+}
+
+func (w *walker) tolerate(err error) bool {
+	if w.opts.Strict {
+		w.fail(err)
+		return false
+	}
+	return w.ctx.Err() == nil
+}
+
+func (w *walker) spawn(wg *sync.WaitGroup, fn func()) {
+	select {
+	case w.tokens <- struct{}{}:
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-w.tokens
+				wg.Done()
+			}()
+			fn()
+		}()
+	default:
+		fn()
+	}
+}
+
+func (w *walker) descends(entry Entry) bool {
+	if entry.Err != nil {
+		return false
+	}
+	return entry.IsDir || (w.opts.Follow && entry.TargetIsDir)
+}
+
+func (w *walker) buildTree(path string, entry Entry, depth int, f *filter, parent *visit) (TreeNode, error) {
+	node := TreeNode{Entry: entry}
+	if !w.descends(entry) || (!w.opts.Sizes && w.opts.MaxDepth > 0 && depth >= w.opts.MaxDepth) {
+		return node, nil
+	}
+
+	id, err := w.src.dirID(path, entry)
+	if err != nil {
+		if !w.tolerate(err) {
+			return TreeNode{}, err
+		}
+		node.Err = err
+		return node, nil
+	}
+	if parent.contains(id) {
+		node.Loop = true
+		return node, nil
+	}
+
+	children, err := w.buildChildren(path, depth, f.enter(path), &visit{id: id, parent: parent})
+	if err != nil {
+		if !w.tolerate(err) {
+			return TreeNode{}, err
+		}
+		node.Err = err
+		return node, nil
+	}
+
+	node.Children = children
+	return node, nil
+}
+
+func (w *walker) buildChildren(path string, depth int, f *filter, parent *visit) ([]TreeNode, error) {
+	limit := 0
+	if !w.opts.Sizes {
+		limit = w.opts.MaxEntries
+	}
+	entries, omitted, err := w.readEntries(path, f, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	children := make([]TreeNode, len(entries))
+	for i, entry := range entries {
+		children[i] = TreeNode{Entry: entry}
+	}
+
+	sortNodes(children, w.opts)
+	if omitted == nil {
+		children, omitted = truncate(children, limit)
+	}
+
+	errs := make([]error, len(children))
+	var wg sync.WaitGroup
+	for i, child := range children {
+		if !w.descends(child.Entry) {
+			continue
+		}
+		w.spawn(&wg, func() {
+			children[i], errs[i] = w.buildTree(filepath.Join(path, child.Name), child.Entry, depth+1, f, parent)
+		})
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if omitted != nil {
+		children = append(children, TreeNode{Omitted: omitted})
+	}
+	return children, nil
+}
+
+// readEntries lstats the entries of a directory that pass the filter.
+// With a limit, entries past it are left unread when their order does not
+// depend on lstat, and are counted in the returned Omitted instead.
+func (w *walker) readEntries(path string, f *filter, limit int) ([]Entry, *Omitted, error) {
+	if err := w.ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	dirEntries, err := w.src.readDir(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	dirsOnly := w.opts.DirsOnly && !w.opts.Sizes
+	kept := dirEntries[:0]
+	for _, de := range dirEntries {
+		if dirsOnly && !de.IsDir() && de.Type()&os.ModeSymlink == 0 {
+			continue
+		}
+		if f.skip(filepath.Join(path, de.Name()), de.IsDir()) {
+			continue
+		}
+		kept = append(kept, de)
+	}
+
+	var omitted *Omitted
+	if limit > 0 && len(kept) > limit && w.direntOrder() {
+		kept, omitted = w.cutDirents(kept, limit)
+	}
+
+	entries := make([]Entry, len(kept))
+	errs := make([]error, len(kept))
+	var wg sync.WaitGroup
+	for start := 0; start < len(kept); start += statBatch {
+		end := min(start+statBatch, len(kept))
+		w.spawn(&wg, func() {
+			for i := start; i < end; i++ {
+				entries[i], errs[i] = w.src.lstatEntry(filepath.Join(path, kept[i].Name()), kept[i].Name())
+			}
+		})
+	}
+	wg.Wait()
+
+	out := entries[:0]
+	for i, entry := range entries {
+		if errs[i] != nil {
+			if !w.tolerate(errs[i]) {
+				return nil, nil, errs[i]
+			}
+			entry = failedEntry(filepath.Join(path, kept[i].Name()), kept[i].Name(), kept[i].IsDir(), errs[i])
+		}
+		w.annotate(&entry)
+		if dirsOnly && !entry.IsDir && !entry.TargetIsDir && entry.Err == nil {
+			continue
+		}
+		if len(w.opts.TypeFilter) > 0 && entry.Err == nil && !w.descends(entry) && !entry.matchesType(w.opts.TypeFilter) {
+			continue
+		}
+		out = append(out, entry)
+	}
+	return out, omitted, nil
+}
+
+// direntOrder reports whether entries can be sorted and cut from their
+// directory entries alone: the sort key is the name and no filter needs
+// lstat to drop entries after the cut.
+func (w *walker) direntOrder() bool {
+	switch w.opts.Sort {
+	case SortName, SortNatural, SortExt, SortNone:
+	default:
+		return false
+	}
+	return !w.opts.DirsOnly && len(w.opts.TypeFilter) == 0
+}
+
+func (w *walker) cutDirents(dirEntries []fs.DirEntry, limit int) ([]fs.DirEntry, *Omitted) {
+	sort.SliceStable(dirEntries, func(i, j int) bool {
+		a := Entry{Name: dirEntries[i].Name(), IsDir: dirEntries[i].IsDir()}
+		b := Entry{Name: dirEntries[j].Name(), IsDir: dirEntries[j].IsDir()}
+		return less(a, b, w.opts)
+	})
+
+	omitted := &Omitted{}
+	for _, de := range dirEntries[limit:] {
+		if de.IsDir() {
+			omitted.Dirs++
+		} else {
+			omitted.Files++
+		}
+	}
+	return dirEntries[:limit], omitted
+}
@@ -0,0 +1,148 @@
+package finder
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func BenchmarkTree(b *testing.B) {
+	root := b.TempDir()
+	makeBenchTree(b, root, 3, 8, 32)
+
+	opts := Options{Sort: SortName, DirsFirst: true}
+	cases := []struct {
+		name string
+		tree func() (TreeNode, error)
+	}{
+		{"recursive", func() (TreeNode, error) { return recursiveTree(root, opts) }},
+		{"workers=1", func() (TreeNode, error) {
+			opts := opts
+			opts.Workers = 1
+			return Tree(context.Background(), root, opts)
+		}},
+		{"parallel", func() (TreeNode, error) { return Tree(context.Background(), root, opts) }},
+	}
+
+	for _, c := range cases {
+		b.Run(c.name, func(b *testing.B) {
+			for b.Loop() {
+				if _, err := c.tree(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// recursiveTree is the walk Tree did before directories were read by a
+// worker pool: one goroutine, one directory at a time. It is the baseline
+// the parallel walker is measured against.
+func recursiveTree(root string, opts Options) (TreeNode, error) {
+	entry, err := disk.statEntry(root, filepath.Base(root))
+	if err != nil {
+		return TreeNode{}, err
+	}
+	f, err := newFilter(root, opts)
+	if err != nil {
+		return TreeNode{}, err
+	}
+
+	var build func(path string, entry Entry, f *filter, parent *visit) TreeNode
+	build = func(path string, entry Entry, f *filter, parent *visit) TreeNode {
+		node := TreeNode{Entry: entry}
+		if !entry.IsDir || entry.Err != nil {
+			return node
+		}
+
+		id, err := disk.dirID(path, entry)
+		if err != nil {
+			node.Err = err
+			return node
+		}
+		if parent.contains(id) {
+			node.Loop = true
+			return node
+		}
+
+		dirents, err := disk.readDir(path)
+		if err != nil {
+			node.Err = err
+			return node
+		}
+		f = f.enter(path)
+		visited := &visit{id: id, parent: parent}
+		for _, e := range dirents {
+			childPath := filepath.Join(path, e.Name())
+			if f.skip(childPath, e.IsDir()) {
+				continue
+			}
+			child, err := disk.lstatEntry(childPath, e.Name())
+			if err != nil {
+				child = failedEntry(childPath, e.Name(), e.IsDir(), err)
+			}
+			node.Children = append(node.Children, TreeNode{Entry: child})
+		}
+
+		sortNodes(node.Children, opts)
+		for i, child := range node.Children {
+			node.Children[i] = build(filepath.Join(path, child.Name), child.Entry, f, visited)
+		}
+		return node
+	}
+	return build(root, entry, f, nil), nil
+}
+
+func makeBenchTree(b *testing.B, dir string, depth, dirs, files int) {
+	b.Helper()
+
+	for i := range files {
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("file%d.txt", i)), nil, 0o644); err != nil {
+			b.Fatal(err)
+		}
+	}
+	if depth == 0 {
+		return
+	}
+
+	for i := range dirs {
+		sub := filepath.Join(dir, fmt.Sprintf("dir%d", i))
+		if err := os.Mkdir(sub, 0o755); err != nil {
+			b.Fatal(err)
+		}
+		makeBenchTree(b, sub, depth-1, dirs, files)
+	}
+}
+
+func TestTreeMaxEntriesCutsBeforeStat(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"c", "a", "e", "b", "d"} {
+		if err := os.WriteFile(filepath.Join(root, name), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(root, "z"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, opts := range []Options{
+		{Sort: SortName, DirsFirst: true, MaxEntries: 3},
+		{Sort: SortSize, DirsFirst: true, MaxEntries: 3},
+	} {
+		tree, err := Tree(context.Background(), root, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var names []string
+		for _, child := range tree.Children[:len(tree.Children)-1] {
+			names = append(names, child.Name)
+		}
+		omitted := tree.Children[len(tree.Children)-1].Omitted
+		if fmt.Sprint(names) != "[z a b]" || omitted == nil || omitted.Files != 3 || omitted.Dirs != 0 {
+			t.Errorf("sort %v: kept %v, omitted %+v; want [z a b] and 3 files", opts.Sort, names, omitted)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package finder
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strconv"
+	"strings"
+)
+
+const (
+	xattrCapability = "security.capability"
+	xattrSELinux    = "security.selinux"
+	xattrACLAccess  = "system.posix_acl_access"
+	xattrACLDefault = "system.posix_acl_default"
+)
+
+func (e Entry) HasACL() bool {
+	for _, name := range e.Xattrs {
+		if name == xattrACLAccess || name == xattrACLDefault {
+			return true
+		}
+	}
+	return false
+}
+
+func (e Entry) HasXattrs() bool {
+	for _, name := range e.Xattrs {
+		switch name {
+		case xattrACLAccess, xattrACLDefault, xattrSELinux:
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+// readAttributes lists the extended attribute names of an entry, and with
+// values also decodes its file capabilities and SELinux label.
+func readAttributes(entry *Entry, values bool) {
+	names, err := listXattrs(entry.Path)
+	if err != nil || len(names) == 0 {
+		return
+	}
+	entry.Xattrs = names
+	if !values {
+		return
+	}
+
+	for _, name := range names {
+		switch name {
+		case xattrCapability:
+			if value, err := getXattr(entry.Path, name); err == nil {
+				entry.Capabilities = decodeCapabilities(value)
+			}
+		case xattrSELinux:
+			if value, err := getXattr(entry.Path, name); err == nil {
+				entry.SELinux = string(bytes.TrimRight(value, "\x00"))
+			}
+		}
+	}
+}
+
+func splitXattrNames(buf []byte) []string {
+	var names []string
+	for _, name := range bytes.Split(buf, []byte{0}) {
+		if len(name) > 0 {
+			names = append(names, string(name))
+		}
+	}
+	return names
+}
+
+const (
+	capRevisionMask = 0xff000000
+	capRevision1    = 0x01000000
+	capRevision2    = 0x02000000
+	capRevision3    = 0x03000000
+	capEffective    = 0x000001
+)
+
+var capNames = []string{
+	"cap_chown", "cap_dac_override", "cap_dac_read_search", "cap_fowner",
+	"cap_fsetid", "cap_kill", "cap_setgid", "cap_setuid", "cap_setpcap",
+	"cap_linux_immutable", "cap_net_bind_service", "cap_net_broadcast",
+	"cap_net_admin", "cap_net_raw", "cap_ipc_lock", "cap_ipc_owner",
+	"cap_sys_module", "cap_sys_rawio", "cap_sys_chroot", "cap_sys_ptrace",
+	"cap_sys_pacct", "cap_sys_admin", "cap_sys_boot", "cap_sys_nice",
+	"cap_sys_resource", "cap_sys_time", "cap_sys_tty_config", "cap_mknod",
+	"cap_lease", "cap_audit_write", "cap_audit_control", "cap_setfcap",
+	"cap_mac_override", "cap_mac_admin", "cap_syslog", "cap_wake_alarm",
+	"cap_block_suspend", "cap_audit_read", "cap_perfmon", "cap_bpf",
+	"cap_checkpoint_restore",
+}
+
+func decodeCapabilities(value []byte) string {
+	if len(value) < 4 {
+		return ""
+	}
+	magic := binary.LittleEndian.Uint32(value)
+
+	words := 0
+	switch magic & capRevisionMask {
+	case capRevision1:
+		words = 1
+	case capRevision2, capRevision3:
+		words = 2
+	default:
+		return ""
+	}
+	if len(value) < 4+words*8 {
+		return ""
+	}
+
+	var permitted, inheritable uint64
+	for i := 0; i < words; i++ {
+		off := 4 + i*8
+		permitted |= uint64(binary.LittleEndian.Uint32(value[off:])) << (32 * i)
+		inheritable |= uint64(binary.LittleEndian.Uint32(value[off+4:])) << (32 * i)
+	}
+	effective := magic&capEffective != 0
+
+	var groups []string
+	var order []string
+	byFlags := map[string][]string{}
+	for bit := 0; bit < 64; bit++ {
+		p := permitted&(1<<uint(bit)) != 0
+		i := inheritable&(1<<uint(bit)) != 0
+		if !p && !i {
+			continue
+		}
+
+		flags := ""
+		if p && effective {
+			flags += "e"
+		}
+		if i {
+			flags += "i"
+		}
+		if p {
+			flags += "p"
+		}
+		if _, ok := byFlags[flags]; !ok {
+			order = append(order, flags)
+		}
+		byFlags[flags] = append(byFlags[flags], capName(bit))
+	}
+	for _, flags := range order {
+		groups = append(groups, strings.Join(byFlags[flags], ",")+"="+flags)
+	}
+	return strings.Join(groups, " ")
+}
+
+func capName(bit int) string {
+	if bit < len(capNames) {
+		return capNames[bit]
+	}
+	return "cap_" + strconv.Itoa(bit)
+}
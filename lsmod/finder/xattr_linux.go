@@ -0,0 +1,94 @@
+package finder
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+func listXattrs(path string) ([]string, error) {
+	p, err := syscall.BytePtrFromString(path)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		size, err := llistxattr(p, nil)
+		if err != nil || size == 0 {
+			return nil, err
+		}
+
+		buf := make([]byte, size)
+		n, err := llistxattr(p, buf)
+		if err == syscall.ERANGE {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return splitXattrNames(buf[:n]), nil
+	}
+}
+
+func getXattr(path, name string) ([]byte, error) {
+	p, err := syscall.BytePtrFromString(path)
+	if err != nil {
+		return nil, err
+	}
+	attr, err := syscall.BytePtrFromString(name)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		size, err := lgetxattr(p, attr, nil)
+		if err != nil || size == 0 {
+			return nil, err
+		}
+
+		buf := make([]byte, size)
+		n, err := lgetxattr(p, attr, buf)
+		if err == syscall.ERANGE {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return buf[:n], nil
+	}
+}
+
+// The buffer pointers are converted inside the Syscall argument lists, as
+// unsafe.Pointer rule 4 requires; an empty buffer asks for the size.
+
+func llistxattr(path *byte, buf []byte) (int, error) {
+	var n uintptr
+	var errno syscall.Errno
+	if len(buf) == 0 {
+		n, _, errno = syscall.Syscall(syscall.SYS_LLISTXATTR,
+			uintptr(unsafe.Pointer(path)), 0, 0)
+	} else {
+		n, _, errno = syscall.Syscall(syscall.SYS_LLISTXATTR,
+			uintptr(unsafe.Pointer(path)), uintptr(unsafe.Pointer(&buf[0])), uintptr(len(buf)))
+	}
+	if errno != 0 {
+		return 0, errno
+	}
+	return int(n), nil
+}
+
+func lgetxattr(path, name *byte, buf []byte) (int, error) {
+	var n uintptr
+	var errno syscall.Errno
+	if len(buf) == 0 {
+		n, _, errno = syscall.Syscall6(syscall.SYS_LGETXATTR,
+			uintptr(unsafe.Pointer(path)), uintptr(unsafe.Pointer(name)), 0, 0, 0, 0)
+	} else {
+		n, _, errno = syscall.Syscall6(syscall.SYS_LGETXATTR,
+			uintptr(unsafe.Pointer(path)), uintptr(unsafe.Pointer(name)),
+			uintptr(unsafe.Pointer(&buf[0])), uintptr(len(buf)), 0, 0)
+	}
+	if errno != 0 {
+		return 0, errno
+	}
+	return int(n), nil
+}
@@ -0,0 +1,11 @@
+//go:build !linux
+
+package finder
+
+func listXattrs(path string) ([]string, error) {
+	return nil, nil
+}
+
+func getXattr(path, name string) ([]byte, error) {
+	return nil, nil
+}
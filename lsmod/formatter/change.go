@@ -0,0 +1,37 @@
+package formatter
+
+import (
+	"fmt"
+	"io"
+)
+
+type Change int
+
+const (
+	ChangeCreated Change = iota + 1
+	ChangeModified
+	ChangeDeleted
+)
+
+var changeLabels = map[Change]struct{ code, text string }{
+	ChangeCreated:  {"1;32", "[new]"},
+	ChangeModified: {"1;33", "[changed]"},
+	ChangeDeleted:  {"1;31", "[deleted]"},
+}
+
+func changeMarker(c Change, p *Palette) string {
+	label, ok := changeLabels[c]
+	if !ok {
+		return ""
+	}
+	return " " + p.wrap(label.code, label.text)
+}
+
+func PrintDeleted(w io.Writer, paths []string, opts Options) error {
+	for _, path := range paths {
+		if _, err := fmt.Fprintln(w, path+changeMarker(ChangeDeleted, opts.Colors)); err != nil {
+			return fmt.Errorf("write deleted entry %s: %w", path, err)
+		}
+	}
+	return nil
+}
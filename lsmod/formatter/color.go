@@ -1,13 +1,203 @@
 package formatter
 
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/ymatsukawa/lsmod/finder"
+)
+
+type ColorMode int
+
 const (
-	colorReset  = "\033[0m"
-	colorYellow = "\033[33m"
+	ColorAuto ColorMode = iota
+	ColorAlways
+	ColorNever
 )
 
-func Colorize(text string, isDir bool) string {
-	if isDir {
-		return colorYellow + text + colorReset
+func ParseColorMode(s string) (ColorMode, error) {
+	switch s {
+	case "auto", "tty", "if-tty":
+		return ColorAuto, nil
+	case "always", "yes", "force":
+		return ColorAlways, nil
+	case "never", "no", "none":
+		return ColorNever, nil
+	}
+	return ColorAuto, fmt.Errorf("unknown color mode %q (want auto, always or never)", s)
+}
+
+const (
+	codeReset = "0"
+	codeRed   = "31"
+	codeDim   = "2"
+)
+
+var defaultColors = map[string]string{
+	"di": "33",
+	"ln": "36",
+	"or": codeRed,
+	"mi": codeRed,
+}
+
+type Palette struct {
+	types map[string]string
+	exts  []extColor
+}
+
+type extColor struct {
+	suffix string
+	code   string
+}
+
+func NewPalette(mode ColorMode, out *os.File) *Palette {
+	if !colorEnabled(mode, out) {
+		return nil
+	}
+	if lsColors := os.Getenv("LS_COLORS"); lsColors != "" {
+		return ParseLSColors(lsColors)
+	}
+	return &Palette{types: defaultColors}
+}
+
+func colorEnabled(mode ColorMode, out *os.File) bool {
+	switch mode {
+	case ColorAlways:
+		return true
+	case ColorNever:
+		return false
+	}
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	return out != nil && IsTerminal(out)
+}
+
+func ParseLSColors(s string) *Palette {
+	p := &Palette{types: make(map[string]string)}
+	for _, item := range strings.Split(s, ":") {
+		key, code, ok := strings.Cut(item, "=")
+		if !ok || code == "" {
+			continue
+		}
+		if suffix, isExt := strings.CutPrefix(key, "*"); isExt {
+			p.exts = append(p.exts, extColor{suffix: strings.ToLower(suffix), code: code})
+			continue
+		}
+		p.types[key] = code
+	}
+
+	sort.SliceStable(p.exts, func(i, j int) bool {
+		return len(p.exts[i].suffix) > len(p.exts[j].suffix)
+	})
+	return p
+}
+
+func (p *Palette) Paint(e finder.Entry, text string) string {
+	if p == nil {
+		return text
+	}
+	return p.wrap(p.code(e), text)
+}
+
+func (p *Palette) PaintTarget(e finder.Entry, text string) string {
+	if p == nil {
+		return text
+	}
+	switch {
+	case e.Broken:
+		return p.wrap(p.types["or"], text)
+	case e.TargetIsDir:
+		return p.wrap(p.types["di"], text)
+	}
+	return p.wrap(p.types["fi"], text)
+}
+
+func (p *Palette) Failure(message string) string {
+	return p.wrap(codeRed, "["+message+"]")
+}
+
+func (p *Palette) Dim(text string) string {
+	return p.wrap(codeDim, text)
+}
+
+func (p *Palette) wrap(code, text string) string {
+	if p == nil || code == "" {
+		return text
+	}
+	return "\033[" + code + "m" + text + "\033[" + codeReset + "m"
+}
+
+func (p *Palette) code(e finder.Entry) string {
+	mode := e.FileMode
+	switch {
+	case e.Err != nil && e.Mode == "":
+		return p.types["mi"]
+	case e.IsLink():
+		if e.Broken {
+			return p.first("or", "ln")
+		}
+		if p.types["ln"] == "target" {
+			if e.TargetIsDir {
+				return p.types["di"]
+			}
+			return p.types["fi"]
+		}
+		return p.types["ln"]
+	case mode.IsDir():
+		sticky := mode&os.ModeSticky != 0
+		otherWritable := mode&0o002 != 0
+		switch {
+		case sticky && otherWritable:
+			return p.first("tw", "ow", "st", "di")
+		case otherWritable:
+			return p.first("ow", "di")
+		case sticky:
+			return p.first("st", "di")
+		}
+		return p.types["di"]
+	case mode&os.ModeNamedPipe != 0:
+		return p.types["pi"]
+	case mode&os.ModeSocket != 0:
+		return p.types["so"]
+	case mode&os.ModeCharDevice != 0:
+		return p.types["cd"]
+	case mode&os.ModeDevice != 0:
+		return p.types["bd"]
+	case mode&os.ModeSetuid != 0 && p.types["su"] != "":
+		return p.types["su"]
+	case mode&os.ModeSetgid != 0 && p.types["sg"] != "":
+		return p.types["sg"]
+	case mode&0o111 != 0 && p.types["ex"] != "":
+		return p.types["ex"]
+	}
+
+	if code := p.extension(e.Name); code != "" {
+		return code
+	}
+	if e.Links > 1 && p.types["mh"] != "" {
+		return p.types["mh"]
+	}
+	return p.types["fi"]
+}
+
+func (p *Palette) first(keys ...string) string {
+	for _, key := range keys {
+		if code := p.types[key]; code != "" {
+			return code
+		}
+	}
+	return ""
+}
+
+func (p *Palette) extension(name string) string {
+	lower := strings.ToLower(name)
+	for _, ext := range p.exts {
+		if strings.HasSuffix(lower, ext.suffix) {
+			return ext.code
+		}
 	}
-	return text
+	return ""
 }
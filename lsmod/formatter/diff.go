@@ -0,0 +1,168 @@
+package formatter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ymatsukawa/lsmod/diff"
+	"github.com/ymatsukawa/lsmod/finder"
+)
+
+var diffMarkers = map[diff.Status]struct{ code, text string }{
+	diff.Same:    {"", " "},
+	diff.Added:   {codeGreen, "+"},
+	diff.Removed: {codeRed, "-"},
+	diff.Changed: {"33", "~"},
+}
+
+var diffStatusNames = map[diff.Status]string{
+	diff.Same:    "same",
+	diff.Added:   "added",
+	diff.Removed: "removed",
+	diff.Changed: "changed",
+}
+
+func PrintDiff(w io.Writer, node diff.Node, opts Options) error {
+	if node.Old != nil && node.New != nil {
+		header := opts.Colors.wrap(codeRed, "--- "+node.Old.Path) + "\n" +
+			opts.Colors.wrap(codeGreen, "+++ "+node.New.Path)
+		if _, err := fmt.Fprintln(w, header); err != nil {
+			return fmt.Errorf("write diff: %w", err)
+		}
+	}
+	if _, err := fmt.Fprintln(w, diffLabel(node, opts)); err != nil {
+		return fmt.Errorf("write diff: %w", err)
+	}
+	if err := printDiffChildren(w, node, "", opts); err != nil {
+		return err
+	}
+
+	added, removed, changed := node.Count()
+	_, err := fmt.Fprintf(w, "\n%d added, %d removed, %d changed\n", added, removed, changed)
+	if err != nil {
+		return fmt.Errorf("write diff summary: %w", err)
+	}
+	return nil
+}
+
+func printDiffChildren(w io.Writer, node diff.Node, prefix string, opts Options) error {
+	for i, child := range node.Children {
+		branch, next := branchMid, prefix+branchPipe
+		if i == len(node.Children)-1 {
+			branch, next = branchLast, prefix+branchNone
+		}
+		if _, err := fmt.Fprintln(w, prefix+branch+diffLabel(child, opts)); err != nil {
+			return fmt.Errorf("write diff node %s: %w", child.Path, err)
+		}
+		if err := printDiffChildren(w, child, next, opts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func diffLabel(node diff.Node, opts Options) string {
+	marker := diffMarkers[node.Status]
+	label := opts.Colors.wrap(marker.code, marker.text+" "+node.Name)
+	if node.Status == diff.Changed {
+		label += " " + opts.Colors.Dim("("+strings.Join(diffDetails(node, opts), ", ")+")")
+	}
+	if node.Err != nil {
+		label += " " + opts.Colors.Failure(errorText(node.Err))
+	}
+	for _, e := range []*finder.Entry{node.Old, node.New} {
+		if e != nil && e.Err != nil {
+			label += " " + opts.Colors.Failure(errorText(e.Err))
+			break
+		}
+	}
+	return label
+}
+
+func diffDetails(node diff.Node, opts Options) []string {
+	a, b := node.Old, node.New
+	var details []string
+	for _, field := range []diff.Field{diff.FieldType, diff.FieldSize, diff.FieldMode, diff.FieldOwner, diff.FieldMtime, diff.FieldContent} {
+		if node.Changes&field == 0 {
+			continue
+		}
+		switch field {
+		case diff.FieldType:
+			if fileType(a.FileMode) != fileType(b.FileMode) {
+				details = append(details, "type "+fileType(a.FileMode)+" → "+fileType(b.FileMode))
+			} else {
+				details = append(details, "target "+a.LinkTarget+" → "+b.LinkTarget)
+			}
+		case diff.FieldSize:
+			details = append(details, "size "+FormatSize(a.Size, opts.Size)+" → "+FormatSize(b.Size, opts.Size))
+		case diff.FieldMode:
+			details = append(details, "mode "+a.Mode+" → "+b.Mode)
+		case diff.FieldOwner:
+			details = append(details, "owner "+a.Owner+":"+a.Group+" → "+b.Owner+":"+b.Group)
+		case diff.FieldMtime:
+			details = append(details, "mtime "+formatTime(a.ModTime, opts)+" → "+formatTime(b.ModTime, opts))
+		case diff.FieldContent:
+			details = append(details, "content")
+		}
+	}
+	return details
+}
+
+type jsonDiff struct {
+	Name     string     `json:"name"`
+	Path     string     `json:"path"`
+	Status   string     `json:"status"`
+	Changes  []string   `json:"changes,omitempty"`
+	Old      *jsonEntry `json:"old,omitempty"`
+	New      *jsonEntry `json:"new,omitempty"`
+	Error    string     `json:"error,omitempty"`
+	Children []jsonDiff `json:"children,omitempty"`
+}
+
+func toJSONDiff(node diff.Node, children bool) jsonDiff {
+	out := jsonDiff{
+		Name:    node.Name,
+		Path:    node.Path,
+		Status:  diffStatusNames[node.Status],
+		Changes: node.Changes.Names(),
+	}
+	if node.Old != nil {
+		e := toJSONEntry(*node.Old)
+		out.Old = &e
+	}
+	if node.New != nil {
+		e := toJSONEntry(*node.New)
+		out.New = &e
+	}
+	if node.Err != nil {
+		out.Error = errorText(node.Err)
+	}
+	if children {
+		for _, child := range node.Children {
+			out.Children = append(out.Children, toJSONDiff(child, true))
+		}
+	}
+	return out
+}
+
+func (jsonEncoder) EncodeDiff(w io.Writer, node diff.Node) error {
+	return writeJSON(w, toJSONDiff(node, true))
+}
+
+func (ndjsonEncoder) EncodeDiff(w io.Writer, node diff.Node) error {
+	return encodeDiffLines(json.NewEncoder(w), node)
+}
+
+func encodeDiffLines(enc *json.Encoder, node diff.Node) error {
+	if err := enc.Encode(toJSONDiff(node, false)); err != nil {
+		return fmt.Errorf("write diff node %s: %w", node.Path, err)
+	}
+	for _, child := range node.Children {
+		if err := encodeDiffLines(enc, child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
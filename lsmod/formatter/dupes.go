@@ -0,0 +1,77 @@
+package formatter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/ymatsukawa/lsmod/dupes"
+)
+
+func PrintDupes(w io.Writer, result dupes.Result, opts Options) error {
+	duplicates := 0
+	for _, g := range result.Groups {
+		duplicates += len(g.Files) - 1
+
+		_, err := fmt.Fprintf(w, "%d copies of %s, %s wasted\n",
+			len(g.Files), totalSize(g.Size, opts.Size), opts.Colors.wrap(codeRed, totalSize(g.Wasted(), opts.Size)))
+		if err != nil {
+			return fmt.Errorf("write duplicate group: %w", err)
+		}
+		for _, f := range g.Files {
+			if _, err := fmt.Fprintln(w, "  "+displayName(f, opts.Colors)); err != nil {
+				return fmt.Errorf("write duplicate %s: %w", f.Name, err)
+			}
+		}
+		if _, err := fmt.Fprintln(w); err != nil {
+			return fmt.Errorf("write duplicate group: %w", err)
+		}
+	}
+
+	_, err := fmt.Fprintf(w, "%d %s, %d duplicate %s, %s wasted\n",
+		len(result.Groups), plural(len(result.Groups), "group", "groups"),
+		duplicates, plural(duplicates, "file", "files"),
+		totalSize(result.Wasted(), opts.Size))
+	if err != nil {
+		return fmt.Errorf("write duplicates summary: %w", err)
+	}
+	return nil
+}
+
+type jsonDupes struct {
+	Groups []jsonDupeGroup `json:"groups"`
+	Wasted int64           `json:"wasted"`
+}
+
+type jsonDupeGroup struct {
+	Size   int64    `json:"size"`
+	SHA256 string   `json:"sha256"`
+	Wasted int64    `json:"wasted"`
+	Files  []string `json:"files"`
+}
+
+func toJSONDupeGroup(g dupes.Group) jsonDupeGroup {
+	out := jsonDupeGroup{Size: g.Size, SHA256: g.Hash, Wasted: g.Wasted()}
+	for _, f := range g.Files {
+		out.Files = append(out.Files, f.Name)
+	}
+	return out
+}
+
+func (jsonEncoder) EncodeDupes(w io.Writer, result dupes.Result) error {
+	out := jsonDupes{Groups: []jsonDupeGroup{}, Wasted: result.Wasted()}
+	for _, g := range result.Groups {
+		out.Groups = append(out.Groups, toJSONDupeGroup(g))
+	}
+	return writeJSON(w, out)
+}
+
+func (ndjsonEncoder) EncodeDupes(w io.Writer, result dupes.Result) error {
+	enc := json.NewEncoder(w)
+	for _, g := range result.Groups {
+		if err := enc.Encode(toJSONDupeGroup(g)); err != nil {
+			return fmt.Errorf("write duplicate group: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package formatter
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/ymatsukawa/lsmod/diff"
+	"github.com/ymatsukawa/lsmod/dupes"
+	"github.com/ymatsukawa/lsmod/finder"
+)
+
+const (
+	OutputText   = "text"
+	OutputJSON   = "json"
+	OutputNDJSON = "ndjson"
+)
+
+type Encoder interface {
+	EncodeEntries(w io.Writer, entries []finder.Entry) error
+	EncodeTree(w io.Writer, node finder.TreeNode) error
+	EncodeDiff(w io.Writer, node diff.Node) error
+	EncodeDupes(w io.Writer, result dupes.Result) error
+}
+
+func NewEncoder(output string, opts Options) (Encoder, error) {
+	if opts.Format != "" && output != "" && output != OutputText {
+		return nil, fmt.Errorf("--format only applies to %s output", OutputText)
+	}
+	if opts.Grid && output != "" && output != OutputText {
+		return nil, fmt.Errorf("-C only applies to %s output", OutputText)
+	}
+	if opts.Export != "" && output != "" && output != OutputText {
+		return nil, fmt.Errorf("--export only applies to %s output", OutputText)
+	}
+	if opts.Export != "" {
+		if err := CheckExport(opts.Export); err != nil {
+			return nil, err
+		}
+	}
+	if opts.Grid && opts.Format != "" {
+		return nil, fmt.Errorf("-C cannot be combined with --format")
+	}
+
+	switch output {
+	case "", OutputText:
+		if opts.Format == "" {
+			return textEncoder{opts: opts}, nil
+		}
+		tmpl, err := parseListTemplate(opts.Format, opts)
+		if err != nil {
+			return nil, err
+		}
+		return textEncoder{opts: opts, tmpl: tmpl}, nil
+	case OutputJSON:
+		return jsonEncoder{}, nil
+	case OutputNDJSON:
+		return ndjsonEncoder{}, nil
+	}
+	return nil, fmt.Errorf("unknown output format %q (want %s, %s or %s)",
+		output, OutputText, OutputJSON, OutputNDJSON)
+}
+
+// ForRoot sets enc up for the output of one of several path arguments;
+// ndjson records then name the argument in a root field.
+func ForRoot(enc Encoder, root string) Encoder {
+	if nd, ok := enc.(ndjsonEncoder); ok {
+		nd.root = root
+		return nd
+	}
+	return enc
+}
+
+type textEncoder struct {
+	opts Options
+	tmpl *listTemplate
+}
+
+func (t textEncoder) EncodeEntries(w io.Writer, entries []finder.Entry) error {
+	if t.tmpl != nil {
+		return t.tmpl.print(w, entries, t.opts)
+	}
+	if t.opts.Grid {
+		return PrintGrid(w, entries, t.opts)
+	}
+	return Print(w, entries, t.opts)
+}
+
+func (t textEncoder) EncodeTree(w io.Writer, node finder.TreeNode) error {
+	if t.opts.Export != "" {
+		return ExportTree(w, node, t.opts)
+	}
+	return PrintTree(w, node, t.opts)
+}
+
+func (t textEncoder) EncodeDiff(w io.Writer, node diff.Node) error {
+	return PrintDiff(w, node, t.opts)
+}
+
+func (t textEncoder) EncodeDupes(w io.Writer, result dupes.Result) error {
+	return PrintDupes(w, result, t.opts)
+}
@@ -0,0 +1,14 @@
+package formatter
+
+import (
+	"errors"
+	"io/fs"
+)
+
+func errorText(err error) string {
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		return pathErr.Err.Error()
+	}
+	return err.Error()
+}
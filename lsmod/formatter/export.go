@@ -0,0 +1,240 @@
+package formatter
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+
+	"github.com/ymatsukawa/lsmod/finder"
+)
+
+const (
+	ExportMarkdown    = "md"
+	ExportMarkdownBox = "md-box"
+	ExportHTML        = "html"
+	ExportMermaid     = "mermaid"
+	ExportDot         = "dot"
+)
+
+var exporters = map[string]func(io.Writer, finder.TreeNode, Options) error{
+	ExportMarkdown:    exportMarkdown,
+	ExportMarkdownBox: exportMarkdownBox,
+	ExportHTML:        exportHTML,
+	ExportMermaid:     exportMermaid,
+	ExportDot:         exportDot,
+}
+
+func CheckExport(format string) error {
+	if _, ok := exporters[format]; !ok {
+		return fmt.Errorf("unknown export format %q (want %s, %s, %s, %s or %s)", format,
+			ExportMarkdown, ExportMarkdownBox, ExportHTML, ExportMermaid, ExportDot)
+	}
+	return nil
+}
+
+func ExportTree(w io.Writer, node finder.TreeNode, opts Options) error {
+	if err := CheckExport(opts.Export); err != nil {
+		return err
+	}
+	opts.Colors = nil
+	if err := exporters[opts.Export](w, node, opts); err != nil {
+		return fmt.Errorf("export %s: %w", opts.Export, err)
+	}
+	return nil
+}
+
+func exportLabel(node finder.TreeNode, opts Options) string {
+	if node.IsDir && !node.Collapsed() {
+		node.Name += "/"
+	}
+	label := nodeName(node, opts)
+	if node.Usage != nil {
+		value := node.Usage.Apparent
+		if opts.Allocated {
+			value = node.Usage.Allocated
+		}
+		label += " (" + FormatSize(value, opts.Size) + ")"
+	}
+	return label
+}
+
+type exportWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (e *exportWriter) printf(format string, args ...any) {
+	if e.err == nil {
+		_, e.err = fmt.Fprintf(e.w, format, args...)
+	}
+}
+
+func exportMarkdown(w io.Writer, node finder.TreeNode, opts Options) error {
+	ew := &exportWriter{w: w}
+	var walk func(node finder.TreeNode, depth int)
+	walk = func(node finder.TreeNode, depth int) {
+		ew.printf("%s- %s\n", strings.Repeat("  ", depth), markdownEscape(exportLabel(node, opts)))
+		for _, child := range node.Children {
+			walk(child, depth+1)
+		}
+	}
+	walk(node, 0)
+	return ew.err
+}
+
+var markdownSpecial = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", `*`, `\*`, `_`, `\_`, `[`, `\[`, `]`, `\]`,
+	`<`, `\<`, `>`, `\>`, `#`, `\#`, `|`, `\|`, `&`, `\&`,
+)
+
+// markdownEscape also escapes a leading list marker (-, + or 1. and 1)),
+// which would otherwise start a nested list inside the item.
+func markdownEscape(s string) string {
+	s = markdownSpecial.Replace(s)
+	if strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+") {
+		return `\` + s
+	}
+	digits := len(s) - len(strings.TrimLeft(s, "0123456789"))
+	if digits > 0 && digits < len(s) && (s[digits] == '.' || s[digits] == ')') {
+		return s[:digits] + `\` + s[digits:]
+	}
+	return s
+}
+
+func exportMarkdownBox(w io.Writer, node finder.TreeNode, opts Options) error {
+	var buf bytes.Buffer
+	if err := PrintTree(&buf, node, opts); err != nil {
+		return err
+	}
+
+	fence := "```"
+	for strings.Contains(buf.String(), fence) {
+		fence += "`"
+	}
+	ew := &exportWriter{w: w}
+	ew.printf("%stext\n%s%s\n", fence, buf.String(), fence)
+	return ew.err
+}
+
+const htmlHead = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>%s</title>
+<style>
+body { font-family: ui-monospace, monospace; }
+ul { list-style: none; margin: 0; padding-left: 1.4em; }
+summary { cursor: pointer; }
+.dir > summary { font-weight: bold; }
+.error { color: #b00; }
+</style>
+</head>
+<body>
+<ul>
+`
+
+const htmlFoot = `</ul>
+</body>
+</html>
+`
+
+func exportHTML(w io.Writer, node finder.TreeNode, opts Options) error {
+	ew := &exportWriter{w: w}
+	ew.printf(htmlHead, html.EscapeString(node.Name))
+
+	var walk func(node finder.TreeNode, depth int)
+	walk = func(node finder.TreeNode, depth int) {
+		indent := strings.Repeat("  ", depth)
+		label := html.EscapeString(exportLabel(node, opts))
+		if node.Err != nil {
+			label = `<span class="error">` + label + `</span>`
+		}
+		if len(node.Children) == 0 {
+			ew.printf("%s<li>%s</li>\n", indent, label)
+			return
+		}
+
+		open := ""
+		if depth == 0 {
+			open = " open"
+		}
+		ew.printf("%s<li><details class=\"dir\"%s><summary>%s</summary>\n%s<ul>\n", indent, open, label, indent)
+		for _, child := range node.Children {
+			walk(child, depth+1)
+		}
+		ew.printf("%s</ul>\n%s</details></li>\n", indent, indent)
+	}
+	walk(node, 0)
+
+	ew.printf("%s", htmlFoot)
+	return ew.err
+}
+
+func exportMermaid(w io.Writer, node finder.TreeNode, opts Options) error {
+	ew := &exportWriter{w: w}
+	ew.printf("flowchart LR\n")
+
+	id := 0
+	var walk func(node finder.TreeNode) int
+	walk = func(node finder.TreeNode) int {
+		self := id
+		id++
+		label := mermaidEscape(exportLabel(node, opts))
+		if node.IsDir && !node.Collapsed() {
+			ew.printf("  n%d[\"%s\"]\n", self, label)
+		} else {
+			ew.printf("  n%d(\"%s\")\n", self, label)
+		}
+		for _, child := range node.Children {
+			ew.printf("  n%d --> n%d\n", self, walk(child))
+		}
+		return self
+	}
+	walk(node)
+	return ew.err
+}
+
+var mermaidSpecial = strings.NewReplacer(`"`, "#quot;", "<", "#lt;", ">", "#gt;")
+
+func mermaidEscape(s string) string {
+	return mermaidSpecial.Replace(s)
+}
+
+func exportDot(w io.Writer, node finder.TreeNode, opts Options) error {
+	ew := &exportWriter{w: w}
+	ew.printf("digraph tree {\n  rankdir=LR;\n  node [shape=box, fontname=\"monospace\"];\n")
+
+	id := 0
+	var walk func(node finder.TreeNode) int
+	walk = func(node finder.TreeNode) int {
+		self := id
+		id++
+		attrs := ""
+		switch {
+		case node.IsDir && !node.Collapsed():
+			attrs = ", shape=folder"
+		case node.Collapsed():
+			attrs = ", style=dashed"
+		}
+		if node.Err != nil {
+			attrs += ", color=red"
+		}
+		ew.printf("  n%d [label=\"%s\"%s];\n", self, dotEscape(exportLabel(node, opts)), attrs)
+		for _, child := range node.Children {
+			ew.printf("  n%d -> n%d;\n", self, walk(child))
+		}
+		return self
+	}
+	walk(node)
+
+	ew.printf("}\n")
+	return ew.err
+}
+
+var dotSpecial = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func dotEscape(s string) string {
+	return dotSpecial.Replace(s)
+}
@@ -0,0 +1,22 @@
+package formatter
+
+import "testing"
+
+func TestMarkdownEscape(t *testing.T) {
+	cases := map[string]string{
+		"1. item":          `1\. item`,
+		"2024) notes":      `2024\) notes`,
+		"- draft":          `\- draft`,
+		"+ plus":           `\+ plus`,
+		"a-b+c.txt":        "a-b+c.txt",
+		"10.txt":           `10\.txt`,
+		"2024":             "2024",
+		"fish &amp; chips": `fish \&amp; chips`,
+		"*_[x]_*":          `\*\_\[x\]\_\*`,
+	}
+	for in, want := range cases {
+		if got := markdownEscape(in); got != want {
+			t.Errorf("markdownEscape(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
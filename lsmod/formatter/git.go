@@ -0,0 +1,49 @@
+package formatter
+
+import "github.com/ymatsukawa/lsmod/finder"
+
+const (
+	codeGreen = "32"
+)
+
+var gitFlagNames = []struct {
+	flag finder.GitStatus
+	name string
+}{
+	{finder.GitStaged, "staged"},
+	{finder.GitModified, "modified"},
+	{finder.GitUntracked, "untracked"},
+	{finder.GitIgnored, "ignored"},
+	{finder.GitConflicted, "conflicted"},
+}
+
+func gitMarker(s finder.GitStatus, p *Palette) string {
+	switch {
+	case s.Has(finder.GitConflicted):
+		return p.wrap(codeRed, "UU")
+	case s.Has(finder.GitStaged) || s.Has(finder.GitModified):
+		x, y := " ", " "
+		if s.Has(finder.GitStaged) {
+			x = p.wrap(codeGreen, "M")
+		}
+		if s.Has(finder.GitModified) {
+			y = p.wrap(codeRed, "M")
+		}
+		return x + y
+	case s.Has(finder.GitUntracked):
+		return p.wrap(codeRed, "??")
+	case s.Has(finder.GitIgnored):
+		return p.Dim("!!")
+	}
+	return "  "
+}
+
+func gitNames(s finder.GitStatus) []string {
+	var names []string
+	for _, f := range gitFlagNames {
+		if s.Has(f.flag) {
+			names = append(names, f.name)
+		}
+	}
+	return names
+}
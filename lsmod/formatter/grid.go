@@ -0,0 +1,93 @@
+package formatter
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/ymatsukawa/lsmod/finder"
+)
+
+const (
+	gridGap      = 2
+	defaultWidth = 80
+)
+
+func columnsEnv() int {
+	cols, err := strconv.Atoi(os.Getenv("COLUMNS"))
+	if err != nil {
+		return 0
+	}
+	return cols
+}
+
+func PrintGrid(w io.Writer, entries []finder.Entry, opts Options) error {
+	names := make([]string, len(entries))
+	widths := make([]int, len(entries))
+	for i, e := range entries {
+		names[i] = opts.Colors.Paint(e, e.Name)
+		widths[i] = visibleWidth(names[i])
+	}
+
+	rows, colWidths := gridLayout(widths, opts.Width)
+	for r := range rows {
+		var line strings.Builder
+		for c, colWidth := range colWidths {
+			i := c*rows + r
+			if i >= len(names) {
+				break
+			}
+			line.WriteString(names[i])
+			if c < len(colWidths)-1 && i+rows < len(names) {
+				line.WriteString(strings.Repeat(" ", colWidth-widths[i]+gridGap))
+			}
+		}
+		if _, err := fmt.Fprintln(w, line.String()); err != nil {
+			return fmt.Errorf("write grid: %w", err)
+		}
+	}
+	return nil
+}
+
+// gridLayout picks the fewest rows whose columns, filled top to bottom,
+// fit in width. A width of zero means one name per line.
+func gridLayout(widths []int, width int) (int, []int) {
+	n := len(widths)
+	if n == 0 {
+		return 0, nil
+	}
+	if width <= 0 {
+		return n, []int{maxOf(widths)}
+	}
+
+	for rows := 1; rows < n; rows++ {
+		cols := (n + rows - 1) / rows
+		if cols*(1+gridGap)-gridGap > width {
+			continue
+		}
+
+		colWidths := make([]int, cols)
+		total := gridGap * (cols - 1)
+		for c := range cols {
+			colWidths[c] = maxOf(widths[c*rows : min((c+1)*rows, n)])
+			total += colWidths[c]
+			if total > width {
+				break
+			}
+		}
+		if total <= width {
+			return rows, colWidths
+		}
+	}
+	return n, []int{maxOf(widths)}
+}
+
+func maxOf(widths []int) int {
+	m := 0
+	for _, w := range widths {
+		m = max(m, w)
+	}
+	return m
+}
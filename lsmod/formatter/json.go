@@ -0,0 +1,253 @@
+package formatter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+
+	"github.com/ymatsukawa/lsmod/finder"
+)
+
+type jsonEntry struct {
+	Root     string      `json:"root,omitempty"`
+	Name     string      `json:"name"`
+	Path     string      `json:"path,omitempty"`
+	Depth    *int        `json:"depth,omitempty"`
+	Type     string      `json:"type"`
+	Mode     string      `json:"mode"`
+	ModeBits uint32      `json:"mode_bits"`
+	Owner    string      `json:"owner"`
+	Group    string      `json:"group"`
+	UID      uint32      `json:"uid"`
+	GID      uint32      `json:"gid"`
+	Updated  string      `json:"updated"`
+	Mtime    int64       `json:"mtime"`
+	Atime    int64       `json:"atime"`
+	Ctime    int64       `json:"ctime"`
+	Birth    *int64      `json:"birth,omitempty"`
+	Size     int64       `json:"size"`
+	Blocks   int64       `json:"blocks"`
+	Links    uint64      `json:"links"`
+	Inode    uint64      `json:"inode"`
+	Device   *jsonDevice `json:"device,omitempty"`
+	Target   string      `json:"target,omitempty"`
+	Broken   bool        `json:"broken,omitempty"`
+	MIME     string      `json:"mime,omitempty"`
+	Xattrs   []string    `json:"xattrs,omitempty"`
+	ACL      bool        `json:"acl,omitempty"`
+	Caps     string      `json:"capabilities,omitempty"`
+	SELinux  string      `json:"selinux,omitempty"`
+	Git      []string    `json:"git,omitempty"`
+	Error    string      `json:"error,omitempty"`
+}
+
+type jsonDevice struct {
+	Major uint32 `json:"major"`
+	Minor uint32 `json:"minor"`
+}
+
+type jsonNode struct {
+	jsonEntry
+	Children []jsonNode   `json:"children,omitempty"`
+	Omitted  *jsonOmitted `json:"omitted,omitempty"`
+	Loop     bool         `json:"loop,omitempty"`
+	Usage    *jsonUsage   `json:"usage,omitempty"`
+}
+
+type jsonUsage struct {
+	Apparent  int64 `json:"apparent"`
+	Allocated int64 `json:"allocated"`
+	Files     int   `json:"files"`
+}
+
+type jsonOmitted struct {
+	Files int `json:"files"`
+	Dirs  int `json:"dirs"`
+}
+
+type jsonEncoder struct{}
+
+func (jsonEncoder) EncodeEntries(w io.Writer, entries []finder.Entry) error {
+	out := make([]jsonEntry, 0, len(entries))
+	for _, e := range entries {
+		out = append(out, toJSONEntry(e))
+	}
+	return writeJSON(w, out)
+}
+
+func (jsonEncoder) EncodeTree(w io.Writer, node finder.TreeNode) error {
+	return writeJSON(w, toJSONNode(node))
+}
+
+// ndjsonEncoder tags every record with root, the path argument it came
+// from, when several paths are listed in one stream.
+type ndjsonEncoder struct {
+	root string
+}
+
+func (n ndjsonEncoder) EncodeEntries(w io.Writer, entries []finder.Entry) error {
+	enc := json.NewEncoder(w)
+	for _, e := range entries {
+		line := toJSONEntry(e)
+		line.Root = n.root
+		if err := enc.Encode(line); err != nil {
+			return fmt.Errorf("write entry %s: %w", e.Name, err)
+		}
+	}
+	return nil
+}
+
+func (n ndjsonEncoder) EncodeTree(w io.Writer, node finder.TreeNode) error {
+	return n.encodeNodeLines(json.NewEncoder(w), node, ".", 0)
+}
+
+func (n ndjsonEncoder) encodeNodeLines(enc *json.Encoder, node finder.TreeNode, rel string, depth int) error {
+	line := jsonNode{jsonEntry: toJSONEntry(node.Entry), Omitted: omittedOf(node), Loop: node.Loop, Usage: usageOf(node.Usage)}
+	line.Root = n.root
+	line.Path = rel
+	line.Depth = &depth
+	if err := enc.Encode(line); err != nil {
+		return fmt.Errorf("write node %s: %w", node.Name, err)
+	}
+
+	for _, child := range node.Children {
+		if child.Collapsed() {
+			continue
+		}
+		if err := n.encodeNodeLines(enc, child, path.Join(rel, child.Name), depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func toJSONEntry(e finder.Entry) jsonEntry {
+	if e.Err != nil && e.Mode == "" {
+		return jsonEntry{Name: e.Name, Type: "unknown", Error: errorText(e.Err)}
+	}
+
+	out := jsonEntry{
+		Name:     e.Name,
+		Type:     fileType(e.FileMode),
+		Mode:     e.Mode,
+		ModeBits: unixMode(e.FileMode),
+		Owner:    e.Owner,
+		Group:    e.Group,
+		UID:      e.UID,
+		GID:      e.GID,
+		Updated:  e.ModTime.Format(timeLayouts[TimeStyleLongISO]),
+		Mtime:    e.ModTime.Unix(),
+		Atime:    e.ATime.Unix(),
+		Ctime:    e.CTime.Unix(),
+		Size:     e.Size,
+		Blocks:   e.Blocks,
+		Links:    e.Links,
+		Inode:    e.Inode,
+		Target:   e.LinkTarget,
+		Broken:   e.Broken,
+		MIME:     e.MIME,
+		Xattrs:   e.Xattrs,
+		ACL:      e.HasACL(),
+		Caps:     e.Capabilities,
+		SELinux:  e.SELinux,
+		Git:      gitNames(e.Git),
+	}
+	if !e.Birth.IsZero() {
+		birth := e.Birth.Unix()
+		out.Birth = &birth
+	}
+	if e.IsDevice() {
+		out.Device = &jsonDevice{Major: e.Major(), Minor: e.Minor()}
+	}
+	if e.Err != nil {
+		out.Error = errorText(e.Err)
+	}
+	return out
+}
+
+func toJSONNode(node finder.TreeNode) jsonNode {
+	out := jsonNode{jsonEntry: toJSONEntry(node.Entry), Omitted: omittedOf(node), Loop: node.Loop, Usage: usageOf(node.Usage)}
+	for _, child := range node.Children {
+		if child.Collapsed() {
+			continue
+		}
+		out.Children = append(out.Children, toJSONNode(child))
+	}
+	return out
+}
+
+func usageOf(u *finder.Usage) *jsonUsage {
+	if u == nil {
+		return nil
+	}
+	return &jsonUsage{Apparent: u.Apparent, Allocated: u.Allocated, Files: u.Files}
+}
+
+func omittedOf(node finder.TreeNode) *jsonOmitted {
+	for _, child := range node.Children {
+		if child.Collapsed() {
+			return &jsonOmitted{Files: child.Omitted.Files, Dirs: child.Omitted.Dirs}
+		}
+	}
+	return nil
+}
+
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return fmt.Errorf("write json: %w", err)
+	}
+	return nil
+}
+
+func fileType(mode os.FileMode) string {
+	switch {
+	case mode.IsDir():
+		return "dir"
+	case mode&os.ModeSymlink != 0:
+		return "symlink"
+	case mode&os.ModeNamedPipe != 0:
+		return "fifo"
+	case mode&os.ModeSocket != 0:
+		return "socket"
+	case mode&os.ModeCharDevice != 0:
+		return "char_device"
+	case mode&os.ModeDevice != 0:
+		return "block_device"
+	}
+	return "file"
+}
+
+func unixMode(mode os.FileMode) uint32 {
+	bits := uint32(mode.Perm())
+	if mode&os.ModeSetuid != 0 {
+		bits |= 0o4000
+	}
+	if mode&os.ModeSetgid != 0 {
+		bits |= 0o2000
+	}
+	if mode&os.ModeSticky != 0 {
+		bits |= 0o1000
+	}
+
+	switch {
+	case mode.IsDir():
+		bits |= 0o040000
+	case mode&os.ModeSymlink != 0:
+		bits |= 0o120000
+	case mode&os.ModeNamedPipe != 0:
+		bits |= 0o010000
+	case mode&os.ModeSocket != 0:
+		bits |= 0o140000
+	case mode&os.ModeCharDevice != 0:
+		bits |= 0o020000
+	case mode&os.ModeDevice != 0:
+		bits |= 0o060000
+	default:
+		bits |= 0o100000
+	}
+	return bits
+}
@@ -0,0 +1,38 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/ymatsukawa/lsmod/finder"
+)
+
+func TestNDJSONRoot(t *testing.T) {
+	entries := []finder.Entry{{Name: "a", Mode: "-rw-r--r--"}, {Name: "b", Mode: "-rw-r--r--"}}
+	for _, root := range []string{"", "src"} {
+		enc, err := NewEncoder(OutputNDJSON, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if root != "" {
+			enc = ForRoot(enc, root)
+		}
+
+		var buf bytes.Buffer
+		if err := enc.EncodeEntries(&buf, entries); err != nil {
+			t.Fatal(err)
+		}
+		for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+			var record map[string]any
+			if err := json.Unmarshal([]byte(line), &record); err != nil {
+				t.Fatal(err)
+			}
+			got, ok := record["root"]
+			if (root == "" && ok) || (root != "" && got != root) {
+				t.Errorf("root %q: record %s", root, line)
+			}
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package formatter
+
+import "github.com/ymatsukawa/lsmod/finder"
+
+func displayName(e finder.Entry, p *Palette) string {
+	name := p.Paint(e, e.Name)
+	if e.IsLink() {
+		name += " -> " + p.PaintTarget(e, e.LinkTarget)
+		if e.Broken {
+			name += " " + p.Failure("broken link")
+		}
+	}
+	if e.Err != nil {
+		name += " " + p.Failure(errorText(e.Err))
+	}
+	return name
+}
+
+func markedName(e finder.Entry, opts Options) string {
+	name := displayName(e, opts.Colors)
+	if opts.Git {
+		name = gitMarker(e.Git, opts.Colors) + " " + name
+	}
+	if c, ok := opts.Changes[e.Path]; ok {
+		name += changeMarker(c, opts.Colors)
+	}
+	return name
+}
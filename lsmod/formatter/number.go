@@ -0,0 +1,42 @@
+package formatter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ymatsukawa/lsmod/finder"
+)
+
+func groupThousands(n int) string {
+	s := strconv.Itoa(n)
+	if len(s) <= 3 {
+		return s
+	}
+
+	var b strings.Builder
+	lead := len(s) % 3
+	if lead > 0 {
+		b.WriteString(s[:lead])
+	}
+	for i := lead; i < len(s); i += 3 {
+		if b.Len() > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(s[i : i+3])
+	}
+	return b.String()
+}
+
+func omittedText(o *finder.Omitted) string {
+	parts := make([]string, 0, 2)
+	if o.Dirs > 0 {
+		parts = append(parts, fmt.Sprintf("%s more %s",
+			groupThousands(o.Dirs), plural(o.Dirs, "directory", "directories")))
+	}
+	if o.Files > 0 {
+		parts = append(parts, fmt.Sprintf("%s more %s",
+			groupThousands(o.Files), plural(o.Files, "file", "files")))
+	}
+	return "… " + strings.Join(parts, ", ")
+}
@@ -0,0 +1,34 @@
+package formatter
+
+import (
+	"time"
+
+	"github.com/ymatsukawa/lsmod/finder"
+)
+
+type SizeStyle int
+
+const (
+	SizeBytes SizeStyle = iota
+	SizeIEC
+	SizeSI
+)
+
+type Options struct {
+	Size      SizeStyle
+	Inode     bool
+	Colors    *Palette
+	Git       bool
+	Bars      bool
+	Allocated bool
+	Xattr     bool
+	Format    string
+	Time      finder.TimeField
+	TimeStyle string
+	Location  *time.Location
+	Changes   map[string]Change
+	MIME      bool
+	Grid      bool
+	Width     int
+	Export    string
+}
@@ -3,18 +3,156 @@ package formatter
 import (
 	"fmt"
 	"io"
+	"strconv"
 
 	"github.com/ymatsukawa/lsmod/finder"
 )
 
-func Print(w io.Writer, entries []finder.Entry) error {
+const unknown = "?"
+
+type row struct {
+	inode string
+	mode  string
+	links string
+	owner string
+	size  string
+	time  string
+	mime  string
+	name  string
+}
+
+func Print(w io.Writer, entries []finder.Entry, opts Options) error {
+	rows := make([]row, 0, len(entries))
 	for _, e := range entries {
-		name := Colorize(e.Name, e.IsDir)
-		_, err := fmt.Fprintf(w, "%s %s:%s [%s] %s\n",
-			e.Mode, e.Owner, e.Group, e.Updated, name)
+		rows = append(rows, newRow(e, opts))
+	}
+	inodeWidth, modeWidth, linksWidth, sizeWidth, mimeWidth := columnWidths(rows)
+
+	for i, r := range rows {
+		if opts.Inode {
+			if _, err := fmt.Fprintf(w, "%*s ", inodeWidth, r.inode); err != nil {
+				return fmt.Errorf("write entry %s: %w", entries[i].Name, err)
+			}
+		}
+
+		name := r.name
+		if opts.MIME {
+			name = opts.Colors.Dim(fmt.Sprintf("%-*s", mimeWidth, r.mime)) + "  " + name
+		}
+
+		_, err := fmt.Fprintf(w, "%-*s %*s %s %*s [%s] %s\n",
+			modeWidth, r.mode, linksWidth, r.links, r.owner, sizeWidth, r.size, r.time, name)
 		if err != nil {
-			return fmt.Errorf("write entry %s: %w", e.Name, err)
+			return fmt.Errorf("write entry %s: %w", entries[i].Name, err)
+		}
+
+		if opts.Xattr {
+			if err := printXattrs(w, entries[i]); err != nil {
+				return err
+			}
 		}
 	}
+
+	return printFooter(w, entries, opts)
+}
+
+func newRow(e finder.Entry, opts Options) row {
+	if e.Err != nil {
+		return row{
+			inode: unknown,
+			mode:  "??????????",
+			links: unknown,
+			owner: unknown + ":" + unknown,
+			size:  unknown,
+			time:  unknown,
+			mime:  unknown,
+			name:  markedName(e, opts),
+		}
+	}
+
+	return row{
+		inode: strconv.FormatUint(e.Inode, 10),
+		mode:  e.Mode + attrMarker(e),
+		links: strconv.FormatUint(e.Links, 10),
+		owner: e.Owner + ":" + e.Group,
+		size:  sizeText(e, opts.Size),
+		time:  formatTime(e.Time(opts.Time), opts),
+		mime:  mimeText(e),
+		name:  markedName(e, opts),
+	}
+}
+
+func columnWidths(rows []row) (inode, mode, links, size, mime int) {
+	for _, r := range rows {
+		inode = max(inode, len(r.inode))
+		mode = max(mode, len(r.mode))
+		links = max(links, len(r.links))
+		size = max(size, len(r.size))
+		mime = max(mime, len(r.mime))
+	}
+	return inode, mode, links, size, mime
+}
+
+func mimeText(e finder.Entry) string {
+	if e.MIME == "" {
+		return unknown
+	}
+	return e.MIME
+}
+
+func sizeText(e finder.Entry, style SizeStyle) string {
+	if e.IsDevice() {
+		return fmt.Sprintf("%d, %d", e.Major(), e.Minor())
+	}
+	return FormatSize(e.Size, style)
+}
+
+// printFooter counts regular files, directories and symlinks; fifos,
+// sockets and devices are "other". Only regular files add to the total.
+func printFooter(w io.Writer, entries []finder.Entry, opts Options) error {
+	var files, dirs, links, other int
+	var total int64
+	for _, e := range entries {
+		switch {
+		case e.Name == "." || e.Name == "..":
+		case e.IsDir:
+			dirs++
+		case e.IsLink():
+			links++
+		case e.FileMode.IsRegular():
+			files++
+			total += e.Size
+		default:
+			other++
+		}
+	}
+
+	counts := fmt.Sprintf("%d %s, %d %s", files, plural(files, "file", "files"),
+		dirs, plural(dirs, "directory", "directories"))
+	if links > 0 {
+		counts += fmt.Sprintf(", %d %s", links, plural(links, "symlink", "symlinks"))
+	}
+	if other > 0 {
+		counts += fmt.Sprintf(", %d other", other)
+	}
+
+	_, err := fmt.Fprintf(w, "%s, %s total\n", counts, totalSize(total, opts.Size))
+	if err != nil {
+		return fmt.Errorf("write footer: %w", err)
+	}
 	return nil
 }
+
+func totalSize(size int64, style SizeStyle) string {
+	if style == SizeBytes {
+		return FormatSize(size, style) + " bytes"
+	}
+	return FormatSize(size, style)
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
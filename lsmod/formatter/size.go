@@ -0,0 +1,42 @@
+package formatter
+
+import (
+	"fmt"
+	"strconv"
+)
+
+var (
+	iecUnits = []string{"KiB", "MiB", "GiB", "TiB", "PiB", "EiB"}
+	siUnits  = []string{"kB", "MB", "GB", "TB", "PB", "EB"}
+)
+
+func FormatSize(size int64, style SizeStyle) string {
+	switch style {
+	case SizeIEC:
+		return humanSize(size, 1024, iecUnits)
+	case SizeSI:
+		return humanSize(size, 1000, siUnits)
+	}
+	return strconv.FormatInt(size, 10)
+}
+
+func humanSize(size int64, base float64, units []string) string {
+	if float64(size) < base {
+		return strconv.FormatInt(size, 10)
+	}
+
+	value := float64(size)
+	unit := ""
+	for _, u := range units {
+		value /= base
+		unit = u
+		if value < base {
+			break
+		}
+	}
+
+	if value < 10 {
+		return fmt.Sprintf("%.1f%s", value, unit)
+	}
+	return fmt.Sprintf("%.0f%s", value, unit)
+}
@@ -0,0 +1,171 @@
+package formatter
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/ymatsukawa/lsmod/finder"
+)
+
+type templateEntry struct {
+	finder.Entry
+	Type    string
+	Marker  string
+	Display string
+	Time    string
+}
+
+type listTemplate struct {
+	columns []*template.Template
+	seps    []string
+}
+
+func parseListTemplate(text string, opts Options) (*listTemplate, error) {
+	now := time.Now()
+	human := opts.Size
+	if human == SizeBytes {
+		human = SizeIEC
+	}
+	funcs := template.FuncMap{
+		"human": func(size int64) string { return FormatSize(size, human) },
+		"rel":   func(t time.Time) string { return relativeTime(t, now) },
+		"octal": func(mode os.FileMode) string { return fmt.Sprintf("%04o", unixMode(mode)&0o7777) },
+		"color": func(e templateEntry) string { return markedName(e.Entry, opts) },
+	}
+
+	parts, seps := splitColumns(text)
+	lt := &listTemplate{seps: seps}
+	for _, part := range parts {
+		tmpl, err := template.New("format").Funcs(funcs).Parse(part)
+		if err != nil {
+			return nil, fmt.Errorf("parse format: %w", err)
+		}
+		lt.columns = append(lt.columns, tmpl)
+	}
+	return lt, nil
+}
+
+// splitColumns cuts a format into columns at runs of spaces outside of
+// actions and outside of if/range/with/block bodies, which have to stay
+// in one template to parse.
+func splitColumns(text string) (parts, seps []string) {
+	blocks := 0
+	start := 0
+	for i := 0; i < len(text); {
+		switch {
+		case strings.HasPrefix(text[i:], "{{"):
+			end := strings.Index(text[i+2:], "}}")
+			if end < 0 {
+				i = len(text)
+				continue
+			}
+			switch actionKeyword(text[i+2 : i+2+end]) {
+			case "if", "range", "with", "block", "define":
+				blocks++
+			case "end":
+				blocks = max(blocks-1, 0)
+			}
+			i += end + 4
+		case text[i] == ' ' && blocks == 0:
+			end := i
+			for i < len(text) && text[i] == ' ' {
+				i++
+			}
+			if end > start {
+				parts = append(parts, text[start:end])
+				seps = append(seps, text[end:i])
+			}
+			start = i
+		default:
+			i++
+		}
+	}
+	if start < len(text) {
+		parts = append(parts, text[start:])
+	}
+	return parts, seps
+}
+
+func actionKeyword(action string) string {
+	action = strings.TrimPrefix(action, "-")
+	fields := strings.Fields(strings.TrimSuffix(action, "-"))
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
+
+func (lt *listTemplate) print(w io.Writer, entries []finder.Entry, opts Options) error {
+	cells := make([][]string, len(entries))
+	for i, e := range entries {
+		data := templateEntry{
+			Entry:   e,
+			Type:    fileType(e.FileMode),
+			Marker:  attrMarker(e),
+			Display: displayName(e, nil),
+			Time:    formatTime(e.Time(opts.Time), opts),
+		}
+
+		cells[i] = make([]string, len(lt.columns))
+		for j, tmpl := range lt.columns {
+			var buf bytes.Buffer
+			if err := tmpl.Execute(&buf, data); err != nil {
+				return fmt.Errorf("format %s: %w", e.Name, err)
+			}
+			cells[i][j] = buf.String()
+		}
+	}
+
+	widths, numeric := columnLayout(cells, len(lt.columns))
+	for i, row := range cells {
+		var line strings.Builder
+		for j, cell := range row {
+			last := j == len(row)-1
+			pad := strings.Repeat(" ", widths[j]-visibleWidth(cell))
+			switch {
+			case numeric[j]:
+				line.WriteString(pad + cell)
+			case last:
+				line.WriteString(cell)
+			default:
+				line.WriteString(cell + pad)
+			}
+			if !last {
+				line.WriteString(lt.seps[j])
+			}
+		}
+		if _, err := fmt.Fprintln(w, line.String()); err != nil {
+			return fmt.Errorf("write entry %s: %w", entries[i].Name, err)
+		}
+	}
+	return nil
+}
+
+func columnLayout(cells [][]string, n int) (widths []int, numeric []bool) {
+	widths = make([]int, n)
+	numeric = make([]bool, n)
+	for j := range numeric {
+		numeric[j] = len(cells) > 0
+	}
+
+	for _, row := range cells {
+		for j, cell := range row {
+			widths[j] = max(widths[j], visibleWidth(cell))
+			if cell == "" {
+				continue
+			}
+			r, _ := utf8.DecodeRuneInString(cell)
+			if !unicode.IsDigit(r) {
+				numeric[j] = false
+			}
+		}
+	}
+	return widths, numeric
+}
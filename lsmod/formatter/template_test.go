@@ -0,0 +1,67 @@
+package formatter
+
+import (
+	"bytes"
+	"io/fs"
+	"testing"
+
+	"github.com/ymatsukawa/lsmod/finder"
+)
+
+func TestListTemplateBlocks(t *testing.T) {
+	entries := []finder.Entry{
+		{Name: "src", IsDir: true, FileMode: fs.ModeDir | 0o755, Size: 4096},
+		{Name: "main.go", FileMode: 0o644, Size: 12},
+	}
+
+	cases := []struct {
+		format string
+		want   string
+	}{
+		{"{{if .IsDir}}dir {{.Name}}{{end}}", "dir src\n\n"},
+		{"{{.Size}} {{if .IsDir}}dir {{.Name}}{{else}}file {{.Name}}{{end}}",
+			"4096 dir src\n  12 file main.go\n"},
+		{"{{.Name}}  {{with .Size}}{{.}} bytes{{end}}", "src      4096 bytes\nmain.go    12 bytes\n"},
+	}
+
+	for _, c := range cases {
+		lt, err := parseListTemplate(c.format, Options{})
+		if err != nil {
+			t.Errorf("%s: %v", c.format, err)
+			continue
+		}
+		var buf bytes.Buffer
+		if err := lt.print(&buf, entries, Options{}); err != nil {
+			t.Errorf("%s: %v", c.format, err)
+			continue
+		}
+		if buf.String() != c.want {
+			t.Errorf("%s: got %q, want %q", c.format, buf.String(), c.want)
+		}
+	}
+}
+
+func TestListTemplateHuman(t *testing.T) {
+	entries := []finder.Entry{{Name: "big", FileMode: 0o644, Size: 1500000}}
+	for _, c := range []struct {
+		size SizeStyle
+		want string
+	}{
+		{SizeBytes, FormatSize(1500000, SizeIEC) + "\n"},
+		{SizeIEC, FormatSize(1500000, SizeIEC) + "\n"},
+		{SizeSI, FormatSize(1500000, SizeSI) + "\n"},
+	} {
+		opts := Options{Size: c.size}
+		lt, err := parseListTemplate("{{human .Size}}", opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		if err := lt.print(&buf, entries, opts); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != c.want {
+			t.Errorf("size style %d: got %q, want %q", c.size, buf.String(), c.want)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package formatter
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+func IsTerminal(f *os.File) bool {
+	var termios syscall.Termios
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(),
+		uintptr(syscall.TCGETS), uintptr(unsafe.Pointer(&termios)))
+	return errno == 0
+}
+
+func TerminalWidth(f *os.File) int {
+	if !IsTerminal(f) {
+		return 0
+	}
+	if cols := columnsEnv(); cols > 0 {
+		return cols
+	}
+
+	var ws struct{ Row, Col, Xpixel, Ypixel uint16 }
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(),
+		uintptr(syscall.TIOCGWINSZ), uintptr(unsafe.Pointer(&ws)))
+	if errno != 0 || ws.Col == 0 {
+		return defaultWidth
+	}
+	return int(ws.Col)
+}
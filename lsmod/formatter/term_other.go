@@ -0,0 +1,23 @@
+//go:build !linux
+
+package formatter
+
+import "os"
+
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+func TerminalWidth(f *os.File) int {
+	if !IsTerminal(f) {
+		return 0
+	}
+	if cols := columnsEnv(); cols > 0 {
+		return cols
+	}
+	return defaultWidth
+}
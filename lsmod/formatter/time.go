@@ -0,0 +1,94 @@
+package formatter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	TimeStyleLongISO  = "long-iso"
+	TimeStyleISO      = "iso"
+	TimeStyleFullISO  = "full-iso"
+	TimeStyleRelative = "relative"
+	TimeStyleUnix     = "unix"
+)
+
+var timeLayouts = map[string]string{
+	TimeStyleLongISO: "2006-01-02 15:04",
+	TimeStyleISO:     time.RFC3339,
+	TimeStyleFullISO: "2006-01-02 15:04:05.000000000 -0700",
+}
+
+func CheckTimeStyle(style string) error {
+	switch {
+	case strings.HasPrefix(style, "+"):
+		return nil
+	case style == TimeStyleRelative, style == TimeStyleUnix:
+		return nil
+	}
+	if _, ok := timeLayouts[style]; ok {
+		return nil
+	}
+	return fmt.Errorf("unknown time style %q (want %s, %s, %s, %s, %s or +LAYOUT)", style,
+		TimeStyleLongISO, TimeStyleISO, TimeStyleFullISO, TimeStyleRelative, TimeStyleUnix)
+}
+
+func formatTime(t time.Time, opts Options) string {
+	if t.IsZero() {
+		return "-"
+	}
+	if opts.Location != nil {
+		t = t.In(opts.Location)
+	}
+
+	switch style := opts.TimeStyle; {
+	case strings.HasPrefix(style, "+"):
+		return t.Format(style[1:])
+	case style == TimeStyleRelative:
+		return relativeTime(t, time.Now())
+	case style == TimeStyleUnix:
+		return strconv.FormatInt(t.Unix(), 10)
+	case timeLayouts[style] != "":
+		return t.Format(timeLayouts[style])
+	}
+	return t.Format(timeLayouts[TimeStyleLongISO])
+}
+
+var timeUnits = []struct {
+	size time.Duration
+	name string
+}{
+	{365 * 24 * time.Hour, "year"},
+	{30 * 24 * time.Hour, "month"},
+	{7 * 24 * time.Hour, "week"},
+	{24 * time.Hour, "day"},
+	{time.Hour, "hour"},
+	{time.Minute, "minute"},
+	{time.Second, "second"},
+}
+
+func relativeTime(t, now time.Time) string {
+	d := now.Sub(t)
+	future := d < 0
+	if future {
+		d = -d
+	}
+	if d < time.Second {
+		return "just now"
+	}
+
+	for _, u := range timeUnits {
+		if d < u.size {
+			continue
+		}
+		n := int(d / u.size)
+		text := fmt.Sprintf("%d %s", n, plural(n, u.name, u.name+"s"))
+		if future {
+			return "in " + text
+		}
+		return text + " ago"
+	}
+	return "just now"
+}
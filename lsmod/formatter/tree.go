@@ -3,6 +3,7 @@ package formatter
 import (
 	"fmt"
 	"io"
+	"strings"
 
 	"github.com/ymatsukawa/lsmod/finder"
 )
@@ -14,32 +15,131 @@ const (
 	branchNone = "    "
 )
 
-func PrintTree(w io.Writer, node finder.TreeNode) error {
-	if _, err := fmt.Fprintln(w, Colorize(node.Name, node.IsDir)); err != nil {
+const barWidth = 20
+
+var barEighths = []string{"", "▏", "▎", "▍", "▌", "▋", "▊", "▉"}
+
+type treePrinter struct {
+	w         io.Writer
+	opts      Options
+	sizeWidth int
+	lineWidth int
+}
+
+func PrintTree(w io.Writer, node finder.TreeNode, opts Options) error {
+	p := &treePrinter{w: w, opts: opts}
+	p.sizeWidth = p.measureSize(node)
+	if opts.Bars {
+		p.lineWidth = p.measureLine(node, 0)
+	}
+
+	if _, err := fmt.Fprintln(w, p.line(node, nil, "")); err != nil {
 		return fmt.Errorf("write root: %w", err)
 	}
-	return printChildren(w, node.Children, "")
+	return p.printChildren(node, "")
 }
 
-func printChildren(w io.Writer, nodes []finder.TreeNode, prefix string) error {
-	for i, node := range nodes {
-		if err := printNode(w, node, prefix, i == len(nodes)-1); err != nil {
+func (p *treePrinter) printChildren(parent finder.TreeNode, prefix string) error {
+	for i, node := range parent.Children {
+		if err := p.printNode(node, parent.Usage, prefix, i == len(parent.Children)-1); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func printNode(w io.Writer, node finder.TreeNode, prefix string, last bool) error {
+func (p *treePrinter) printNode(node finder.TreeNode, parent *finder.Usage, prefix string, last bool) error {
 	branch, next := branchMid, prefix+branchPipe
 	if last {
 		branch, next = branchLast, prefix+branchNone
 	}
 
-	name := Colorize(node.Name, node.IsDir)
-	if _, err := fmt.Fprintf(w, "%s%s%s\n", prefix, branch, name); err != nil {
+	if _, err := fmt.Fprintln(p.w, p.line(node, parent, prefix+branch)); err != nil {
 		return fmt.Errorf("write node %s: %w", node.Name, err)
 	}
 
-	return printChildren(w, node.Children, next)
+	return p.printChildren(node, next)
+}
+
+func (p *treePrinter) line(node finder.TreeNode, parent *finder.Usage, prefix string) string {
+	line := prefix + p.label(node)
+	if p.opts.Bars && node.Usage != nil && parent != nil {
+		line += strings.Repeat(" ", p.lineWidth-visibleWidth(line)) + "  " +
+			bar(p.usageValue(*node.Usage), p.usageValue(*parent))
+	}
+	return line
+}
+
+func (p *treePrinter) label(node finder.TreeNode) string {
+	name := nodeName(node, p.opts)
+	if node.Usage == nil {
+		return name
+	}
+
+	label := fmt.Sprintf("[%*s]  %s", p.sizeWidth, p.usageSize(*node.Usage), name)
+	if node.IsDir && !node.Collapsed() {
+		label += " " + p.opts.Colors.Dim(fmt.Sprintf("(%s %s)",
+			groupThousands(node.Usage.Files), plural(node.Usage.Files, "file", "files")))
+	}
+	return label
+}
+
+func (p *treePrinter) measureSize(node finder.TreeNode) int {
+	if node.Usage == nil {
+		return 0
+	}
+	width := len(p.usageSize(*node.Usage))
+	for _, child := range node.Children {
+		width = max(width, p.measureSize(child))
+	}
+	return width
+}
+
+func (p *treePrinter) measureLine(node finder.TreeNode, depth int) int {
+	width := depth*visibleWidth(branchMid) + visibleWidth(p.label(node))
+	for _, child := range node.Children {
+		width = max(width, p.measureLine(child, depth+1))
+	}
+	return width
+}
+
+func (p *treePrinter) usageValue(u finder.Usage) int64 {
+	if p.opts.Allocated {
+		return u.Allocated
+	}
+	return u.Apparent
+}
+
+func (p *treePrinter) usageSize(u finder.Usage) string {
+	return FormatSize(p.usageValue(u), p.opts.Size)
+}
+
+func bar(value, total int64) string {
+	fraction := 0.0
+	if total > 0 {
+		fraction = float64(value) / float64(total)
+	}
+
+	eighths := int(fraction * barWidth * 8)
+	cells := strings.Repeat("█", eighths/8) + barEighths[eighths%8]
+	used := eighths / 8
+	if eighths%8 > 0 {
+		used++
+	}
+	return cells + strings.Repeat(" ", barWidth-used) + fmt.Sprintf(" %3.0f%%", fraction*100)
+}
+
+func nodeName(node finder.TreeNode, opts Options) string {
+	if node.Collapsed() {
+		return opts.Colors.Dim(omittedText(node.Omitted))
+	}
+
+	name := markedName(node.Entry, opts)
+	if node.Loop {
+		name += " " + opts.Colors.Dim("[recursive, not followed]")
+	}
+	if opts.MIME && node.MIME != "" && !node.IsDir {
+		name += " " + opts.Colors.Dim("["+node.MIME+"]")
+	}
+	return name
 }
@@ -0,0 +1,34 @@
+package formatter
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/ymatsukawa/lsmod/finder"
+)
+
+func attrMarker(e finder.Entry) string {
+	switch {
+	case e.HasACL():
+		return "+"
+	case e.HasXattrs():
+		return "@"
+	}
+	return ""
+}
+
+func printXattrs(w io.Writer, e finder.Entry) error {
+	for _, name := range e.Xattrs {
+		line := "\t" + name
+		switch {
+		case name == "security.capability" && e.Capabilities != "":
+			line += "\t" + e.Capabilities
+		case name == "security.selinux" && e.SELinux != "":
+			line += "\t" + e.SELinux
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return fmt.Errorf("write attributes of %s: %w", e.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package query
+
+import (
+	"context"
+	"path"
+	"path/filepath"
+
+	"github.com/ymatsukawa/lsmod/finder"
+)
+
+type Result struct {
+	Matches []finder.Entry
+	Tree    finder.TreeNode
+	Failed  int
+}
+
+func Find(ctx context.Context, root string, q *Query, opts finder.Options) (Result, error) {
+	opts.Sizes = false
+	opts.MaxEntries = 0
+	opts.DirsOnly = false
+
+	tree, err := finder.Tree(ctx, root, opts)
+	if err != nil {
+		return Result{}, err
+	}
+
+	result := Result{Failed: tree.ErrorCount()}
+	var walk func(node finder.TreeNode, rel string) (finder.TreeNode, bool)
+	walk = func(node finder.TreeNode, rel string) (finder.TreeNode, bool) {
+		matched := node.Err == nil && q.Match(node.Entry, rel)
+		if matched {
+			entry := node.Entry
+			entry.Name = filepath.Join(root, filepath.FromSlash(rel))
+			result.Matches = append(result.Matches, entry)
+		}
+
+		children := node.Children
+		node.Children = nil
+		for _, child := range children {
+			if kept, ok := walk(child, path.Join(rel, child.Name)); ok {
+				node.Children = append(node.Children, kept)
+			}
+		}
+		return node, matched || len(node.Children) > 0
+	}
+	result.Tree, _ = walk(tree, ".")
+	return result, nil
+}
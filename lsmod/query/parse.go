@@ -0,0 +1,250 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/ymatsukawa/lsmod/finder"
+)
+
+type Query struct {
+	root expr
+}
+
+func (q *Query) Match(e finder.Entry, rel string) bool {
+	return q.root.match(candidate{Entry: e, rel: rel})
+}
+
+type candidate struct {
+	finder.Entry
+	rel string
+}
+
+type expr interface {
+	match(c candidate) bool
+}
+
+type andExpr struct{ left, right expr }
+type orExpr struct{ left, right expr }
+type notExpr struct{ inner expr }
+
+func (e andExpr) match(c candidate) bool { return e.left.match(c) && e.right.match(c) }
+func (e orExpr) match(c candidate) bool  { return e.left.match(c) || e.right.match(c) }
+func (e notExpr) match(c candidate) bool { return !e.inner.match(c) }
+
+type token struct {
+	text   string
+	quoted bool
+}
+
+func (t token) is(words ...string) bool {
+	if t.quoted {
+		return false
+	}
+	for _, w := range words {
+		if t.text == w {
+			return true
+		}
+	}
+	return false
+}
+
+func Parse(s string, now time.Time) (*Query, error) {
+	tokens, err := lex(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty expression")
+	}
+
+	p := &parser{tokens: tokens, now: now}
+	root, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q", p.tokens[p.pos].text)
+	}
+	return &Query{root: root}, nil
+}
+
+// lex splits an expression into words and parentheses. Quotes group a
+// value that contains spaces or parentheses and are removed. A leading !
+// that is not part of != or !~ becomes a token of its own, so it negates
+// the predicate that follows whether or not its value is quoted.
+func lex(s string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case unicode.IsSpace(rune(c)):
+			i++
+			continue
+		case c == '(' || c == ')':
+			tokens = append(tokens, token{text: string(c)})
+			i++
+			continue
+		case c == '!' && i+1 < len(s) && s[i+1] != '=' && s[i+1] != '~':
+			tokens = append(tokens, token{text: "!"})
+			i++
+			continue
+		}
+
+		var word strings.Builder
+		quoted := false
+		for i < len(s) && !unicode.IsSpace(rune(s[i])) && s[i] != '(' && s[i] != ')' {
+			if q := s[i]; q == '\'' || q == '"' {
+				end := strings.IndexByte(s[i+1:], q)
+				if end < 0 {
+					return nil, fmt.Errorf("unterminated quote in %q", s[i:])
+				}
+				word.WriteString(s[i+1 : i+1+end])
+				i += end + 2
+				quoted = true
+				continue
+			}
+			word.WriteByte(s[i])
+			i++
+		}
+		tokens = append(tokens, token{text: word.String(), quoted: quoted})
+	}
+	return tokens, nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+	now    time.Time
+}
+
+func (p *parser) peek() (token, bool) {
+	if p.pos >= len(p.tokens) {
+		return token{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+func (p *parser) next() (token, bool) {
+	t, ok := p.peek()
+	if ok {
+		p.pos++
+	}
+	return t, ok
+}
+
+func (p *parser) or() (expr, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t, ok := p.peek()
+		if !ok || !t.is("or", "||") {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		left = orExpr{left, right}
+	}
+}
+
+func (p *parser) and() (expr, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t, ok := p.peek()
+		if !ok || t.is(")", "or", "||") {
+			return left, nil
+		}
+		if t.is("and", "&&") {
+			p.pos++
+		}
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		left = andExpr{left, right}
+	}
+}
+
+func (p *parser) unary() (expr, error) {
+	t, ok := p.next()
+	if !ok {
+		return nil, fmt.Errorf("expression ends early")
+	}
+
+	switch {
+	case t.is("not", "!"):
+		inner, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return notExpr{inner}, nil
+	case t.is("("):
+		inner, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if t, ok := p.next(); !ok || !t.is(")") {
+			return nil, fmt.Errorf("missing )")
+		}
+		return inner, nil
+	case t.is(")", "and", "&&", "or", "||"):
+		return nil, fmt.Errorf("unexpected %q", t.text)
+	}
+
+	text := t.text
+	if field, ok := bareField(t); ok {
+		op, ok := p.next()
+		if !ok || op.quoted || !hasOperator(field+op.text) {
+			return nil, fmt.Errorf("%s: missing operator", field)
+		}
+		text = field + op.text
+		if _, _, value, _ := splitPredicate(text); value == "" {
+			if v, ok := p.next(); ok {
+				text += v.text
+			}
+		}
+	}
+	return parsePredicate(text, p.now)
+}
+
+func bareField(t token) (string, bool) {
+	if t.quoted || t.text == "" {
+		return "", false
+	}
+	for _, r := range t.text {
+		if r < 'a' || r > 'z' {
+			return "", false
+		}
+	}
+	return t.text, true
+}
+
+var operators = []string{"!=", "<=", ">=", "!~", "=", "<", ">", "~"}
+
+func splitPredicate(s string) (field, op, value string, ok bool) {
+	i := strings.IndexFunc(s, func(r rune) bool { return r < 'a' || r > 'z' })
+	if i <= 0 {
+		return "", "", "", false
+	}
+	for _, op := range operators {
+		if strings.HasPrefix(s[i:], op) {
+			return s[:i], op, s[i+len(op):], true
+		}
+	}
+	return "", "", "", false
+}
+
+func hasOperator(s string) bool {
+	_, _, _, ok := splitPredicate(s)
+	return ok
+}
@@ -0,0 +1,61 @@
+package query
+
+import (
+	"io/fs"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ymatsukawa/lsmod/finder"
+)
+
+func TestParseQuoted(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	entries := map[string]finder.Entry{
+		"main.go":       {Name: "main.go", FileMode: 0o644, Size: 10},
+		"my notes.txt":  {Name: "my notes.txt", FileMode: 0o644, Size: 2048},
+		"report (1).md": {Name: "report (1).md", FileMode: 0o600, Size: 0},
+		"build":         {Name: "build", FileMode: fs.ModeDir | 0o755, IsDir: true},
+	}
+
+	cases := []struct {
+		expr string
+		want []string
+	}{
+		{`name='my notes.txt'`, []string{"my notes.txt"}},
+		{`name = "my notes.txt"`, []string{"my notes.txt"}},
+		{`name~'notes'`, []string{"my notes.txt"}},
+		{`!name~'notes'`, []string{"build", "main.go", "report (1).md"}},
+		{`!name~notes`, []string{"build", "main.go", "report (1).md"}},
+		{`! name ~ 'notes'`, []string{"build", "main.go", "report (1).md"}},
+		{`not name='report (1).md'`, []string{"build", "main.go", "my notes.txt"}},
+		{`!(name='*.go' or type=d)`, []string{"my notes.txt", "report (1).md"}},
+		{`name!='*.go' and !type=d`, []string{"my notes.txt", "report (1).md"}},
+		{`name!~"^(main|build)"`, []string{"my notes.txt", "report (1).md"}},
+		{`!name~'notes`, nil},
+	}
+
+	for _, c := range cases {
+		q, err := Parse(c.expr, now)
+		if c.want == nil {
+			if err == nil {
+				t.Errorf("%s: parsed, want an error", c.expr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", c.expr, err)
+			continue
+		}
+
+		var got []string
+		for _, name := range []string{"build", "main.go", "my notes.txt", "report (1).md"} {
+			if q.Match(entries[name], name) {
+				got = append(got, name)
+			}
+		}
+		if !slices.Equal(got, c.want) {
+			t.Errorf("%s: matched %q, want %q", c.expr, got, c.want)
+		}
+	}
+}
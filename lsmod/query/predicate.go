@@ -0,0 +1,335 @@
+package query
+
+import (
+	"fmt"
+	"io/fs"
+	"math"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type predicate func(c candidate) bool
+
+func (p predicate) match(c candidate) bool { return p(c) }
+
+var fields = map[string]func(op, value string, now time.Time) (predicate, error){
+	"name":  stringField(func(c candidate) string { return c.Name }),
+	"path":  stringField(func(c candidate) string { return c.rel }),
+	"owner": idField(func(c candidate) (string, uint32) { return c.Owner, c.UID }),
+	"group": idField(func(c candidate) (string, uint32) { return c.Group, c.GID }),
+	"type":  typeField,
+	"size":  numberField(parseSize, func(c candidate) int64 { return c.Size }),
+	"links": numberField(parseCount, func(c candidate) int64 { return int64(c.Links) }),
+	"mtime": timeField(func(c candidate) time.Time { return c.ModTime }),
+	"atime": timeField(func(c candidate) time.Time { return c.ATime }),
+	"ctime": timeField(func(c candidate) time.Time { return c.CTime }),
+	"perm":  permField,
+}
+
+const fieldList = "name, path, type, size, links, mtime, atime, ctime, perm, owner or group"
+
+func parsePredicate(s string, now time.Time) (expr, error) {
+	field, op, value, ok := splitPredicate(s)
+	if !ok {
+		return nil, fmt.Errorf("%q is not a predicate like size>10M", s)
+	}
+	build, ok := fields[field]
+	if !ok {
+		return nil, fmt.Errorf("unknown field %q (want %s)", field, fieldList)
+	}
+	p, err := build(op, value, now)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", s, err)
+	}
+	return p, nil
+}
+
+func unsupported(op string) error {
+	return fmt.Errorf("operator %s is not supported here", op)
+}
+
+func negate(op string, p predicate) predicate {
+	if op != "!=" && op != "!~" {
+		return p
+	}
+	return func(c candidate) bool { return !p(c) }
+}
+
+func stringField(get func(candidate) string) func(string, string, time.Time) (predicate, error) {
+	return func(op, value string, _ time.Time) (predicate, error) {
+		switch op {
+		case "=", "!=":
+			if _, err := path.Match(value, ""); err != nil {
+				return nil, fmt.Errorf("invalid glob: %w", err)
+			}
+			return negate(op, func(c candidate) bool {
+				ok, _ := path.Match(value, get(c))
+				return ok
+			}), nil
+		case "~", "!~":
+			re, err := regexp.Compile(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid regexp: %w", err)
+			}
+			return negate(op, func(c candidate) bool { return re.MatchString(get(c)) }), nil
+		}
+		return nil, unsupported(op)
+	}
+}
+
+func idField(get func(candidate) (string, uint32)) func(string, string, time.Time) (predicate, error) {
+	return func(op, value string, now time.Time) (predicate, error) {
+		if op == "~" || op == "!~" {
+			return stringField(func(c candidate) string {
+				name, _ := get(c)
+				return name
+			})(op, value, now)
+		}
+		if op != "=" && op != "!=" {
+			return nil, unsupported(op)
+		}
+
+		id, numeric := strconv.ParseUint(value, 10, 32)
+		return negate(op, func(c candidate) bool {
+			name, cid := get(c)
+			return name == value || (numeric == nil && uint64(cid) == id)
+		}), nil
+	}
+}
+
+var fileTypes = map[string]func(fs.FileMode) bool{
+	"f": fs.FileMode.IsRegular,
+	"d": fs.FileMode.IsDir,
+	"l": func(m fs.FileMode) bool { return m&fs.ModeSymlink != 0 },
+	"p": func(m fs.FileMode) bool { return m&fs.ModeNamedPipe != 0 },
+	"s": func(m fs.FileMode) bool { return m&fs.ModeSocket != 0 },
+	"b": func(m fs.FileMode) bool { return m&fs.ModeDevice != 0 && m&fs.ModeCharDevice == 0 },
+	"c": func(m fs.FileMode) bool { return m&fs.ModeCharDevice != 0 },
+}
+
+func typeField(op, value string, _ time.Time) (predicate, error) {
+	if op != "=" && op != "!=" {
+		return nil, unsupported(op)
+	}
+	is, ok := fileTypes[value]
+	if !ok {
+		return nil, fmt.Errorf("unknown type %q (want f, d, l, p, s, b or c)", value)
+	}
+	return negate(op, func(c candidate) bool { return is(c.FileMode) }), nil
+}
+
+func compare(op string, a, b int64) bool {
+	switch op {
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	case ">":
+		return a > b
+	case ">=":
+		return a >= b
+	case "!=":
+		return a != b
+	}
+	return a == b
+}
+
+func numberField(parse func(string) (int64, error), get func(candidate) int64) func(string, string, time.Time) (predicate, error) {
+	return func(op, value string, _ time.Time) (predicate, error) {
+		if op == "~" || op == "!~" {
+			return nil, unsupported(op)
+		}
+		n, err := parse(value)
+		if err != nil {
+			return nil, err
+		}
+		return func(c candidate) bool { return compare(op, get(c), n) }, nil
+	}
+}
+
+func parseCount(s string) (int64, error) {
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %q", s)
+	}
+	return n, nil
+}
+
+var sizeUnits = map[string]float64{
+	"": 1, "b": 1,
+	"k": 1 << 10, "kib": 1 << 10, "kb": 1e3,
+	"m": 1 << 20, "mib": 1 << 20, "mb": 1e6,
+	"g": 1 << 30, "gib": 1 << 30, "gb": 1e9,
+	"t": 1 << 40, "tib": 1 << 40, "tb": 1e12,
+}
+
+var sizePattern = regexp.MustCompile(`^([0-9]+(?:\.[0-9]+)?)([A-Za-z]*)$`)
+
+func parseSize(s string) (int64, error) {
+	m := sizePattern.FindStringSubmatch(s)
+	if m == nil {
+		return 0, fmt.Errorf("invalid size %q (want a number like 512, 10K, 1.5M or 2G)", s)
+	}
+	unit, ok := sizeUnits[strings.ToLower(m[2])]
+	if !ok {
+		return 0, fmt.Errorf("unknown size unit %q", m[2])
+	}
+	n, _ := strconv.ParseFloat(m[1], 64)
+	return int64(math.Round(n * unit)), nil
+}
+
+var ageUnits = map[string]time.Duration{
+	"s": time.Second,
+	"m": time.Minute,
+	"h": time.Hour,
+	"d": 24 * time.Hour,
+	"w": 7 * 24 * time.Hour,
+	"y": 365 * 24 * time.Hour,
+}
+
+var agePattern = regexp.MustCompile(`^([0-9]+)([smhdwy])$`)
+
+var dateLayouts = []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02 15:04", "2006-01-02"}
+
+// timeField compares ages for durations (mtime<7d: changed within the
+// last week) and instants for dates (mtime>2024-01-01: changed after it).
+func timeField(get func(candidate) time.Time) func(string, string, time.Time) (predicate, error) {
+	return func(op, value string, now time.Time) (predicate, error) {
+		if op == "~" || op == "!~" {
+			return nil, unsupported(op)
+		}
+
+		if m := agePattern.FindStringSubmatch(value); m != nil {
+			n, _ := strconv.ParseInt(m[1], 10, 64)
+			unit := ageUnits[m[2]]
+			return func(c candidate) bool {
+				age := now.Sub(get(c))
+				if op == "=" || op == "!=" {
+					return compare(op, int64(age/unit), n)
+				}
+				return compare(op, int64(age), n*int64(unit))
+			}, nil
+		}
+
+		for _, layout := range dateLayouts {
+			t, err := time.ParseInLocation(layout, value, now.Location())
+			if err != nil {
+				continue
+			}
+			span := time.Second
+			if layout == "2006-01-02" {
+				span = 24 * time.Hour
+			} else if layout == "2006-01-02 15:04" {
+				span = time.Minute
+			}
+			return func(c candidate) bool {
+				ts := get(c)
+				switch op {
+				case "=", "!=":
+					within := !ts.Before(t) && ts.Before(t.Add(span))
+					return within == (op == "=")
+				case "<":
+					return ts.Before(t)
+				case "<=":
+					return ts.Before(t.Add(span))
+				case ">":
+					return !ts.Before(t.Add(span))
+				}
+				return !ts.Before(t)
+			}, nil
+		}
+		return nil, fmt.Errorf("invalid time %q (want an age like 30m, 7d or 2w, or a date like 2024-01-31)", value)
+	}
+}
+
+func permField(op, value string, _ time.Time) (predicate, error) {
+	if op != "=" && op != "!=" {
+		return nil, unsupported(op)
+	}
+	mask, want, err := parsePerm(value)
+	if err != nil {
+		return nil, err
+	}
+	return negate(op, func(c candidate) bool { return unixPerm(c.FileMode)&mask == want }), nil
+}
+
+func unixPerm(m fs.FileMode) uint32 {
+	perm := uint32(m.Perm())
+	if m&fs.ModeSetuid != 0 {
+		perm |= 0o4000
+	}
+	if m&fs.ModeSetgid != 0 {
+		perm |= 0o2000
+	}
+	if m&fs.ModeSticky != 0 {
+		perm |= 0o1000
+	}
+	return perm
+}
+
+var permClasses = map[byte]uint32{'u': 0o4700, 'g': 0o2070, 'o': 0o1007}
+
+// parsePerm turns an octal mode (exact match) or chmod-style clauses such
+// as o+w or u=rwx,g-w into the bits to test and their expected values.
+func parsePerm(s string) (mask, want uint32, err error) {
+	if n, err := strconv.ParseUint(s, 8, 32); err == nil && n <= 0o7777 {
+		return 0o7777, uint32(n), nil
+	}
+
+	for _, clause := range strings.Split(s, ",") {
+		i := strings.IndexAny(clause, "+-=")
+		if i < 0 {
+			return 0, 0, fmt.Errorf("invalid permission %q (want 644 or a clause like o+w)", s)
+		}
+		who, op, perms := clause[:i], clause[i], clause[i+1:]
+		if who == "" || who == "a" {
+			who = "ugo"
+		}
+
+		var class, bits uint32
+		for j := 0; j < len(who); j++ {
+			c, ok := permClasses[who[j]]
+			if !ok {
+				return 0, 0, fmt.Errorf("invalid permission class %q in %q", who[j], s)
+			}
+			class |= c
+			for _, p := range perms {
+				b, err := permBit(who[j], p)
+				if err != nil {
+					return 0, 0, fmt.Errorf("%w in %q", err, s)
+				}
+				bits |= b
+			}
+		}
+
+		switch op {
+		case '+':
+			mask, want = mask|bits, want|bits
+		case '-':
+			mask, want = mask|bits, want&^bits
+		case '=':
+			mask, want = mask|class, want&^class|bits
+		}
+	}
+	return mask, want, nil
+}
+
+func permBit(class byte, p rune) (uint32, error) {
+	shift := map[byte]uint{'u': 6, 'g': 3, 'o': 0}[class]
+	switch p {
+	case 'r':
+		return 4 << shift, nil
+	case 'w':
+		return 2 << shift, nil
+	case 'x':
+		return 1 << shift, nil
+	case 's':
+		return map[byte]uint32{'u': 0o4000, 'g': 0o2000}[class], nil
+	case 't':
+		return map[byte]uint32{'o': 0o1000}[class], nil
+	}
+	return 0, fmt.Errorf("invalid permission %q", p)
+}
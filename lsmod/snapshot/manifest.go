@@ -0,0 +1,63 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+const manifestVersion = 1
+
+type Manifest struct {
+	Version   int       `json:"version"`
+	Root      string    `json:"root"`
+	Created   time.Time `json:"created"`
+	Checksums bool      `json:"checksums"`
+	Entries   []Record  `json:"entries"`
+}
+
+type Record struct {
+	Path   string    `json:"path"`
+	Type   string    `json:"type"`
+	Size   int64     `json:"size"`
+	Mode   string    `json:"mode"`
+	UID    uint32    `json:"uid"`
+	GID    uint32    `json:"gid"`
+	Owner  string    `json:"owner,omitempty"`
+	Group  string    `json:"group,omitempty"`
+	Mtime  time.Time `json:"mtime"`
+	SHA256 string    `json:"sha256,omitempty"`
+	Target string    `json:"target,omitempty"`
+	Error  string    `json:"error,omitempty"`
+}
+
+func (m Manifest) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(m); err != nil {
+		return fmt.Errorf("write manifest: %w", err)
+	}
+	return nil
+}
+
+func Load(path string) (Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Manifest{}, fmt.Errorf("read manifest: %w", err)
+	}
+
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return Manifest{}, fmt.Errorf("parse manifest %s: %w", path, err)
+	}
+	if m.Version != manifestVersion {
+		return Manifest{}, fmt.Errorf("manifest %s: unsupported version %d", path, m.Version)
+	}
+	if len(m.Entries) == 0 || m.Entries[0].Path != "." {
+		return Manifest{}, errors.New("manifest " + path + ": missing root entry")
+	}
+	return m, nil
+}
@@ -0,0 +1,231 @@
+package snapshot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/ymatsukawa/lsmod/finder"
+)
+
+type Options struct {
+	Finder    finder.Options
+	Checksums bool
+}
+
+func Save(ctx context.Context, root string, opts Options) (Manifest, int, error) {
+	opts.Finder.Sort = finder.SortName
+	opts.Finder.Sizes = false
+	opts.Finder.MaxEntries = 0
+	opts.Finder.MaxDepth = 0
+
+	tree, err := finder.Tree(ctx, root, opts.Finder)
+	if err != nil {
+		return Manifest{}, 0, err
+	}
+
+	var files *finder.Files
+	if opts.Checksums {
+		if files, err = finder.OpenFiles(root, opts.Finder); err != nil {
+			return Manifest{}, 0, err
+		}
+		defer files.Close()
+	}
+
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return Manifest{}, 0, fmt.Errorf("resolve path %s: %w", root, err)
+	}
+	m := Manifest{
+		Version:   manifestVersion,
+		Root:      abs,
+		Created:   time.Now().UTC(),
+		Checksums: opts.Checksums,
+	}
+
+	failed := 0
+	var walk func(node finder.TreeNode, rel string) error
+	walk = func(node finder.TreeNode, rel string) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		rec := record(node.Entry, rel)
+		if rec.Error == "" && opts.Checksums && node.FileMode.IsRegular() {
+			sum, err := files.Checksum(ctx, node.Path)
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if err != nil {
+				rec.Error = err.Error()
+			}
+			rec.SHA256 = sum
+		}
+		if rec.Error != "" {
+			failed++
+		}
+		m.Entries = append(m.Entries, rec)
+
+		for _, child := range node.Children {
+			if child.Collapsed() {
+				continue
+			}
+			if err := walk(child, path.Join(rel, child.Name)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := walk(tree, "."); err != nil {
+		return Manifest{}, 0, err
+	}
+	return m, failed, nil
+}
+
+func record(e finder.Entry, rel string) Record {
+	if e.Err != nil {
+		return Record{Path: rel, Type: typeName(e.FileMode), Error: e.Err.Error()}
+	}
+	return Record{
+		Path:   rel,
+		Type:   typeName(e.FileMode),
+		Size:   e.Size,
+		Mode:   fmt.Sprintf("%04o", permBits(e.FileMode)),
+		UID:    e.UID,
+		GID:    e.GID,
+		Owner:  e.Owner,
+		Group:  e.Group,
+		Mtime:  e.ModTime.UTC(),
+		Target: e.LinkTarget,
+	}
+}
+
+func Tree(m Manifest) (finder.TreeNode, map[string]string, error) {
+	entries := make(map[string]finder.Entry, len(m.Entries))
+	children := make(map[string][]string)
+	sums := make(map[string]string)
+
+	for _, rec := range m.Entries {
+		entry, err := entryOf(rec)
+		if err != nil {
+			return finder.TreeNode{}, nil, fmt.Errorf("manifest entry %s: %w", rec.Path, err)
+		}
+		if _, ok := entries[rec.Path]; ok {
+			return finder.TreeNode{}, nil, fmt.Errorf("manifest entry %s: duplicate path", rec.Path)
+		}
+		entries[rec.Path] = entry
+		if rec.SHA256 != "" {
+			sums[entry.Path] = rec.SHA256
+		}
+		if rec.Path != "." {
+			parent := path.Dir(rec.Path)
+			children[parent] = append(children[parent], rec.Path)
+		}
+	}
+
+	for parent := range children {
+		if _, ok := entries[parent]; !ok {
+			return finder.TreeNode{}, nil, fmt.Errorf("manifest entry %s: parent is missing", children[parent][0])
+		}
+	}
+
+	var build func(rel string) finder.TreeNode
+	build = func(rel string) finder.TreeNode {
+		node := finder.TreeNode{Entry: entries[rel]}
+		for _, child := range children[rel] {
+			node.Children = append(node.Children, build(child))
+		}
+		return node
+	}
+	return build("."), sums, nil
+}
+
+func entryOf(rec Record) (finder.Entry, error) {
+	typ, ok := typeBits[rec.Type]
+	if !ok {
+		return finder.Entry{}, fmt.Errorf("unknown type %q", rec.Type)
+	}
+
+	entry := finder.Entry{
+		Name:     path.Base(rec.Path),
+		Path:     "snapshot:" + rec.Path,
+		IsDir:    rec.Type == "dir",
+		FileMode: typ,
+	}
+	if rec.Error != "" {
+		entry.Err = errors.New(rec.Error)
+		return entry, nil
+	}
+
+	bits, err := strconv.ParseUint(rec.Mode, 8, 32)
+	if err != nil {
+		return finder.Entry{}, fmt.Errorf("bad mode %q", rec.Mode)
+	}
+	entry.FileMode |= modeOf(uint32(bits))
+	entry.Mode = finder.ModeString(entry.FileMode)
+	entry.Size = rec.Size
+	entry.UID, entry.GID = rec.UID, rec.GID
+	entry.Owner, entry.Group = nameOr(rec.Owner, rec.UID), nameOr(rec.Group, rec.GID)
+	entry.ModTime = rec.Mtime
+	entry.LinkTarget = rec.Target
+	return entry, nil
+}
+
+func nameOr(name string, id uint32) string {
+	if name != "" {
+		return name
+	}
+	return strconv.FormatUint(uint64(id), 10)
+}
+
+var typeBits = map[string]os.FileMode{
+	"file":         0,
+	"dir":          os.ModeDir,
+	"symlink":      os.ModeSymlink,
+	"fifo":         os.ModeNamedPipe,
+	"socket":       os.ModeSocket,
+	"char_device":  os.ModeDevice | os.ModeCharDevice,
+	"block_device": os.ModeDevice,
+}
+
+func typeName(mode os.FileMode) string {
+	for name, bits := range typeBits {
+		if bits != 0 && mode&os.ModeType == bits {
+			return name
+		}
+	}
+	return "file"
+}
+
+func permBits(mode os.FileMode) uint32 {
+	bits := uint32(mode.Perm())
+	if mode&os.ModeSetuid != 0 {
+		bits |= 0o4000
+	}
+	if mode&os.ModeSetgid != 0 {
+		bits |= 0o2000
+	}
+	if mode&os.ModeSticky != 0 {
+		bits |= 0o1000
+	}
+	return bits
+}
+
+func modeOf(bits uint32) os.FileMode {
+	mode := os.FileMode(bits & 0o777)
+	if bits&0o4000 != 0 {
+		mode |= os.ModeSetuid
+	}
+	if bits&0o2000 != 0 {
+		mode |= os.ModeSetgid
+	}
+	if bits&0o1000 != 0 {
+		mode |= os.ModeSticky
+	}
+	return mode
+}
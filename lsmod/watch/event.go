@@ -0,0 +1,33 @@
+package watch
+
+import "time"
+
+type Op int
+
+const (
+	Create Op = iota
+	Modify
+	Delete
+	Chmod
+)
+
+func (o Op) String() string {
+	switch o {
+	case Create:
+		return "CREATE"
+	case Modify:
+		return "MODIFY"
+	case Delete:
+		return "DELETE"
+	case Chmod:
+		return "CHMOD"
+	}
+	return "UNKNOWN"
+}
+
+type Event struct {
+	Path  string
+	Op    Op
+	IsDir bool
+	Time  time.Time
+}
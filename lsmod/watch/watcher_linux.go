@@ -0,0 +1,192 @@
+package watch
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+const watchMask = syscall.IN_CREATE | syscall.IN_DELETE | syscall.IN_MODIFY |
+	syscall.IN_ATTRIB | syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO |
+	syscall.IN_DELETE_SELF
+
+type Watcher struct {
+	Events chan Event
+	Errors chan error
+
+	file      *os.File
+	fd        int
+	recursive bool
+
+	mu    sync.Mutex
+	paths map[int32]string
+}
+
+func New(root string, recursive bool) (*Watcher, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, fmt.Errorf("inotify init: %w", err)
+	}
+
+	w := &Watcher{
+		Events:    make(chan Event, 256),
+		Errors:    make(chan error, 1),
+		file:      os.NewFile(uintptr(fd), "inotify"),
+		fd:        fd,
+		recursive: recursive,
+		paths:     make(map[int32]string),
+	}
+
+	if err := w.addTree(root, nil); err != nil {
+		w.file.Close()
+		return nil, err
+	}
+
+	go w.read()
+	return w, nil
+}
+
+func (w *Watcher) Close() error {
+	return w.file.Close()
+}
+
+func (w *Watcher) addTree(root string, created func(path string, isDir bool)) error {
+	if err := w.add(root); err != nil {
+		return err
+	}
+	if !w.recursive {
+		return nil
+	}
+
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || path == root {
+			return nil
+		}
+		if created != nil {
+			created(path, d.IsDir())
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		return w.add(path)
+	})
+}
+
+func (w *Watcher) add(path string) error {
+	wd, err := syscall.InotifyAddWatch(w.fd, path, watchMask|syscall.IN_ONLYDIR|syscall.IN_DONT_FOLLOW)
+	switch {
+	case errors.Is(err, syscall.ENOSPC):
+		return fmt.Errorf("watch %s: inotify watch limit reached (raise fs.inotify.max_user_watches)", path)
+	case errors.Is(err, syscall.ENOENT), errors.Is(err, syscall.EACCES), errors.Is(err, syscall.ENOTDIR):
+		return nil
+	case err != nil:
+		return fmt.Errorf("watch %s: %w", path, err)
+	}
+
+	w.mu.Lock()
+	w.paths[int32(wd)] = path
+	w.mu.Unlock()
+	return nil
+}
+
+func (w *Watcher) forget(wd int32) {
+	w.mu.Lock()
+	delete(w.paths, wd)
+	w.mu.Unlock()
+}
+
+func (w *Watcher) report(err error) {
+	select {
+	case w.Errors <- err:
+	default:
+	}
+}
+
+func (w *Watcher) read() {
+	defer close(w.Events)
+
+	buf := make([]byte, 64*(syscall.SizeofInotifyEvent+syscall.NAME_MAX+1))
+	for {
+		n, err := w.file.Read(buf)
+		if err != nil {
+			if !errors.Is(err, os.ErrClosed) {
+				w.report(fmt.Errorf("read inotify events: %w", err))
+			}
+			return
+		}
+		w.parse(buf[:n])
+	}
+}
+
+func (w *Watcher) parse(buf []byte) {
+	now := time.Now()
+	for len(buf) >= syscall.SizeofInotifyEvent {
+		raw := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[0]))
+		size := syscall.SizeofInotifyEvent + int(raw.Len)
+		name := ""
+		if raw.Len > 0 {
+			name = cString(buf[syscall.SizeofInotifyEvent:size])
+		}
+		buf = buf[size:]
+
+		if raw.Mask&syscall.IN_IGNORED != 0 {
+			w.forget(raw.Wd)
+			continue
+		}
+		if raw.Mask&syscall.IN_Q_OVERFLOW != 0 {
+			w.report(errors.New("inotify queue overflow, some events were lost"))
+			continue
+		}
+
+		w.mu.Lock()
+		dir, ok := w.paths[raw.Wd]
+		w.mu.Unlock()
+		if !ok || raw.Mask&syscall.IN_DELETE_SELF != 0 {
+			continue
+		}
+
+		path := filepath.Join(dir, name)
+		isDir := raw.Mask&syscall.IN_ISDIR != 0
+		w.dispatch(path, raw.Mask, isDir, now)
+	}
+}
+
+func (w *Watcher) dispatch(path string, mask uint32, isDir bool, now time.Time) {
+	emit := func(path string, op Op, isDir bool) {
+		w.Events <- Event{Path: path, Op: op, IsDir: isDir, Time: now}
+	}
+
+	switch {
+	case mask&(syscall.IN_CREATE|syscall.IN_MOVED_TO) != 0:
+		emit(path, Create, isDir)
+		if isDir && w.recursive {
+			err := w.addTree(path, func(child string, childIsDir bool) {
+				emit(child, Create, childIsDir)
+			})
+			if err != nil {
+				w.report(err)
+			}
+		}
+	case mask&(syscall.IN_DELETE|syscall.IN_MOVED_FROM) != 0:
+		emit(path, Delete, isDir)
+	case mask&syscall.IN_ATTRIB != 0:
+		emit(path, Chmod, isDir)
+	case mask&(syscall.IN_MODIFY) != 0:
+		emit(path, Modify, isDir)
+	}
+}
+
+func cString(b []byte) string {
+	for i, c := range b {
+		if c == 0 {
+			return string(b[:i])
+		}
+	}
+	return string(b)
+}
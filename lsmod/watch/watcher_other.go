@@ -0,0 +1,18 @@
+//go:build !linux
+
+package watch
+
+import "errors"
+
+type Watcher struct {
+	Events chan Event
+	Errors chan error
+}
+
+func New(root string, recursive bool) (*Watcher, error) {
+	return nil, errors.New("watch is only supported on Linux")
+}
+
+func (w *Watcher) Close() error {
+	return nil
+}